@@ -0,0 +1,15 @@
+//go:build !release
+
+// Package assets holds the game's images and maps. Release builds, made with
+// the release build tag, embed them into the binary so it runs from
+// anywhere; other builds read them from the assets directory of the working
+// directory, so they can be edited without building again.
+package assets
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FS holds the game's assets, at paths like "maps/spawn.json".
+var FS fs.FS = os.DirFS("assets")
@@ -0,0 +1,14 @@
+//go:build release
+
+package assets
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed images maps
+var embedded embed.FS
+
+// FS holds the game's assets, at paths like "maps/spawn.json".
+var FS fs.FS = embedded
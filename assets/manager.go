@@ -0,0 +1,292 @@
+package assets
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// kinds of assets a Manager loads
+const (
+	AudioAsset   = "audio"
+	ImageAsset   = "image"
+	MapAsset     = "map"
+	TilesetAsset = "tileset"
+)
+
+type key struct {
+	kind string
+	path string
+}
+
+type entry struct {
+	done  chan struct{} // closed once loading the asset is over
+	err   error
+	refs  int
+	size  int // bytes, roughly
+	value any
+}
+
+// loaded reports whether the asset is loaded, rather than still loading.
+func (e *entry) loaded() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Info describes a loaded asset, see Manager.Loaded.
+type Info struct {
+	Kind string
+	Path string
+	Refs int // scopes using it
+	Size int // bytes of memory it takes, roughly
+}
+
+// Manager loads every asset from its file system only once, however many
+// scopes ask for it, and frees it again once none of them uses it anymore.
+// It's safe for concurrent use.
+type Manager struct {
+	entries map[key]*entry
+	fsys    fs.FS
+	mu      sync.Mutex
+}
+
+func NewManager(fsys fs.FS) *Manager {
+	return &Manager{
+		entries: make(map[key]*entry),
+		fsys:    fsys,
+	}
+}
+
+// FS returns the file system the manager loads from, for files it doesn't
+// keep, e.g. world files.
+func (m *Manager) FS() fs.FS {
+	return m.fsys
+}
+
+// NewScope returns an empty scope to load assets through.
+func (m *Manager) NewScope() *Scope {
+	return &Scope{
+		keys:    make([]key, 0),
+		manager: m,
+	}
+}
+
+// Loaded returns what's loaded, sorted by kind and path. Assets still
+// loading aren't.
+func (m *Manager) Loaded() []Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loaded := make([]Info, 0, len(m.entries))
+	for k, e := range m.entries {
+		if !e.loaded() {
+			continue
+		}
+
+		loaded = append(loaded, Info{Kind: k.kind, Path: k.path, Refs: e.refs, Size: e.size})
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		if loaded[i].Kind != loaded[j].Kind {
+			return loaded[i].Kind < loaded[j].Kind
+		}
+		return loaded[i].Path < loaded[j].Path
+	})
+
+	return loaded
+}
+
+// Report lists what's loaded, one asset per line, for debugging.
+func (m *Manager) Report() string {
+	var sb strings.Builder
+
+	total := 0
+	for _, info := range m.Loaded() {
+		fmt.Fprintf(&sb, "%-7s %-40s refs %d, %d KiB\n", info.Kind, info.Path, info.Refs, info.Size/1024)
+		total += info.Size
+	}
+	fmt.Fprintf(&sb, "%d KiB in total\n", total/1024)
+
+	return sb.String()
+}
+
+// acquire returns the asset at k, calling load to load it if it isn't yet,
+// and counts one more reference to it. Other assets can be acquired while
+// load runs; whoever asks for the same one meanwhile waits for it instead
+// of loading it again.
+func (m *Manager) acquire(k key, load func() (any, int, error)) (any, error) {
+	m.mu.Lock()
+	if existing, exists := m.entries[k]; exists {
+		existing.refs++
+		m.mu.Unlock()
+
+		<-existing.done
+		if existing.err != nil {
+			return nil, existing.err
+		}
+		return existing.value, nil
+	}
+
+	loading := &entry{done: make(chan struct{}), refs: 1}
+	m.entries[k] = loading
+	m.mu.Unlock()
+
+	value, size, err := load()
+
+	m.mu.Lock()
+	loading.err, loading.size, loading.value = err, size, value
+	if err != nil {
+		// the next one asking tries again
+		delete(m.entries, k)
+	}
+	m.mu.Unlock()
+	close(loading.done)
+
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// release drops a reference to the asset at k, freeing it if it was the
+// last one.
+func (m *Manager) release(k key) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, exists := m.entries[k]
+	if !exists {
+		return
+	}
+
+	existing.refs--
+	if existing.refs > 0 {
+		return
+	}
+
+	delete(m.entries, k)
+	if img, ok := existing.value.(*ebiten.Image); ok {
+		img.Deallocate()
+	}
+}
+
+// Scope is a set of references to assets that are released together, e.g.
+// everything one map needs. Asking a scope for the same asset twice counts
+// two references, both of which Release drops.
+type Scope struct {
+	keys    []key
+	manager *Manager
+	mu      sync.Mutex
+}
+
+// Audio returns the contents of a sound file, for an audio decoder to play.
+func (s *Scope) Audio(audioPath string) ([]byte, error) {
+	value, err := s.acquire(key{AudioAsset, path.Clean(audioPath)}, func(fsys fs.FS, p string) (any, int, error) {
+		contents, err := fs.ReadFile(fsys, p)
+		return contents, len(contents), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]byte), nil
+}
+
+func (s *Scope) Image(imgPath string) (*ebiten.Image, error) {
+	value, err := s.acquire(key{ImageAsset, path.Clean(imgPath)}, func(fsys fs.FS, p string) (any, int, error) {
+		img, _, err := ebitenutil.NewImageFromFileSystem(fsys, p)
+		if err != nil {
+			return nil, 0, err
+		}
+		return img, img.Bounds().Dx() * img.Bounds().Dy() * 4, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*ebiten.Image), nil
+}
+
+// Map returns the map at mapPath. Everyone using the map shares it, so tile
+// edits made through one scope show in all of them. prepare, if not nil, is
+// called with the map right after it's loaded, before anyone gets it, and
+// not again for as long as it stays loaded.
+func (s *Scope) Map(mapPath string, prepare func(*tilemaps.TileMapJSON) error) (*tilemaps.TileMapJSON, error) {
+	value, err := s.acquire(key{MapAsset, path.Clean(mapPath)}, func(fsys fs.FS, p string) (any, int, error) {
+		tileMap, err := tilemaps.NewTileMap(fsys, p)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if prepare != nil {
+			if err := prepare(tileMap); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		size := 0
+		for _, layer := range tileMap.FlatLayers() {
+			size += 4 * len(layer.Data)
+			for _, chunk := range layer.Chunks {
+				size += 4 * len(chunk.Data)
+			}
+		}
+		return tileMap, size, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*tilemaps.TileMapJSON), nil
+}
+
+func (s *Scope) Tileset(tilesetPath string) (*tilemaps.TilesetJSON, error) {
+	value, err := s.acquire(key{TilesetAsset, path.Clean(tilesetPath)}, func(fsys fs.FS, p string) (any, int, error) {
+		tilesetJson, err := tilemaps.NewTilesetJSON(fsys, p)
+		return tilesetJson, 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*tilemaps.TilesetJSON), nil
+}
+
+// Release drops every reference the scope holds. The scope can be used
+// again afterwards.
+func (s *Scope) Release() {
+	s.mu.Lock()
+	keys := s.keys
+	s.keys = make([]key, 0)
+	s.mu.Unlock()
+
+	for _, k := range keys {
+		s.manager.release(k)
+	}
+}
+
+func (s *Scope) acquire(k key, load func(fsys fs.FS, p string) (any, int, error)) (any, error) {
+	value, err := s.manager.acquire(k, func() (any, int, error) {
+		return load(s.manager.fsys, k.path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.keys = append(s.keys, k)
+	s.mu.Unlock()
+
+	return value, nil
+}
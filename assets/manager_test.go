@@ -0,0 +1,76 @@
+package assets
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAcquireLoadsOnce(t *testing.T) {
+	m := NewManager(fstest.MapFS{})
+	slow := key{AudioAsset, "slow.ogg"}
+
+	loads := 0
+	unblock := make(chan struct{})
+	loadSlow := func() (any, int, error) {
+		loads++
+		<-unblock
+		return []byte("slow"), 4, nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]any, 2)
+	for i := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], _ = m.acquire(slow, loadSlow)
+		}()
+	}
+
+	// other assets load while the slow one does
+	fast, err := m.acquire(key{AudioAsset, "fast.ogg"}, func() (any, int, error) {
+		return []byte("fast"), 4, nil
+	})
+	if err != nil || string(fast.([]byte)) != "fast" {
+		t.Fatalf("got %v, %v", fast, err)
+	}
+	if loaded := m.Loaded(); len(loaded) != 1 || loaded[0].Path != "fast.ogg" {
+		t.Errorf("got loaded %v while slow.ogg is loading", loaded)
+	}
+
+	close(unblock)
+	wg.Wait()
+
+	if loads != 1 {
+		t.Errorf("loaded slow.ogg %d times", loads)
+	}
+	for _, value := range values {
+		if string(value.([]byte)) != "slow" {
+			t.Errorf("got %v", value)
+		}
+	}
+
+	m.release(slow)
+	m.release(slow)
+	if loaded := m.Loaded(); len(loaded) != 1 {
+		t.Errorf("got loaded %v after releasing slow.ogg", loaded)
+	}
+}
+
+func TestAcquireFailure(t *testing.T) {
+	m := NewManager(fstest.MapFS{})
+	k := key{AudioAsset, "broken.ogg"}
+	errBroken := errors.New("broken")
+
+	if _, err := m.acquire(k, func() (any, int, error) { return nil, 0, errBroken }); !errors.Is(err, errBroken) {
+		t.Fatalf("got error %v", err)
+	}
+
+	// failures aren't cached
+	value, err := m.acquire(k, func() (any, int, error) { return []byte("fixed"), 5, nil })
+	if err != nil || string(value.([]byte)) != "fixed" {
+		t.Errorf("got %v, %v", value, err)
+	}
+}
@@ -0,0 +1,58 @@
+package autotiles
+
+import (
+	"fmt"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// custom tile layer property naming the wang set whose tiles ResolveLayers
+// places on the layer
+const WangSetProperty = "wangset"
+
+// WangSets finds the wang sets of a map's tilesets, see tilesets.Set.
+type WangSets interface {
+	WangSet(name string) (*tilemaps.WangSetJSON, int, error)
+}
+
+// ResolveLayers places the tiles fitting the terrain of every tile layer
+// naming a wang set in its WangSetProperty property, when the map is
+// loaded. The terrain is read from the set's tiles already on the layer, so
+// a map can be painted with whole tiles of each terrain and get the
+// transitions between them placed here. Only the set's tiles are replaced,
+// others on the layer stay where they are.
+func ResolveLayers(tileMap *tilemaps.TileMapJSON, sets WangSets) error {
+	for _, layer := range tileMap.FlatLayers() {
+		name := layer.Properties.String(WangSetProperty, "")
+		if layer.Type != tilemaps.TileLayer || name == "" {
+			continue
+		}
+
+		wangSet, firstGid, err := sets.WangSet(name)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", layer.Name, err)
+		}
+
+		resolver := NewResolver(wangSet, firstGid)
+		terrain := NewTerrain(layer.Bounds())
+		if err := resolver.Read(tileMap, layer.Name, terrain); err != nil {
+			return err
+		}
+
+		var setErr error
+		layer.EachTile(func(x, y int, rawId uint32) {
+			if _, ok := resolver.WangId(rawId); !ok || setErr != nil {
+				return
+			}
+
+			if resolved, ok := resolver.Resolve(terrain.WangId(x, y), x, y); ok {
+				setErr = tileMap.SetTile(layer.Name, x, y, resolved)
+			}
+		})
+		if setErr != nil {
+			return fmt.Errorf("layer %q: %w", layer.Name, setErr)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package autotiles
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// Resolver picks the tiles of a wang set that fit a terrain.
+type Resolver struct {
+	firstGid int
+	tiles    map[tilemaps.WangId][]int // tile ids by their masked wang id
+	wangIds  map[int]tilemaps.WangId   // masked wang ids by tile id
+	wangSet  *tilemaps.WangSetJSON
+}
+
+// NewResolver makes a resolver for wangSet, which belongs to the tileset
+// starting at firstGid, see tilesets.Set.WangSet.
+func NewResolver(wangSet *tilemaps.WangSetJSON, firstGid int) *Resolver {
+	r := Resolver{
+		firstGid: firstGid,
+		tiles:    make(map[tilemaps.WangId][]int),
+		wangIds:  make(map[int]tilemaps.WangId),
+		wangSet:  wangSet,
+	}
+
+	for _, wangTile := range wangSet.WangTiles {
+		wangId := wangTile.WangId.Masked(wangSet.Type)
+		r.tiles[wangId] = append(r.tiles[wangId], wangTile.TileId)
+		r.wangIds[wangTile.TileId] = wangId
+	}
+
+	return &r
+}
+
+// Resolve returns the gid of the tile to place at tile x, y for wangId. If
+// the set has no tile matching it exactly, it's the one with the fewest
+// parts differing. Where several tiles fit equally well, x and y pick one,
+// so that the same terrain always gets the same tiles. It returns false if
+// wangId has no terrain the set uses.
+func (r *Resolver) Resolve(wangId tilemaps.WangId, x, y int) (uint32, bool) {
+	wangId = wangId.Masked(r.wangSet.Type)
+	if wangId == (tilemaps.WangId{}) {
+		return 0, false
+	}
+
+	candidates := r.tiles[wangId]
+	if len(candidates) == 0 {
+		candidates = r.closest(wangId)
+	}
+	if len(candidates) == 0 {
+		return 0, false
+	}
+
+	// a cheap hash, so that neighbouring tiles rarely pick the same one
+	pick := uint32(x)*73856093 ^ uint32(y)*19349663
+	return uint32(r.firstGid + candidates[pick%uint32(len(candidates))]), true
+}
+
+// closest returns the tile ids whose wang ids differ from wangId in the
+// fewest parts.
+func (r *Resolver) closest(wangId tilemaps.WangId) []int {
+	closest := make([]int, 0)
+	minDiff := len(wangId) + 1
+	for _, wangTile := range r.wangSet.WangTiles {
+		tileWangId := r.wangIds[wangTile.TileId]
+
+		diff := 0
+		for part := range wangId {
+			if tileWangId[part] != wangId[part] {
+				diff++
+			}
+		}
+
+		switch {
+		case diff < minDiff:
+			closest, minDiff = append(closest[:0], wangTile.TileId), diff
+		case diff == minDiff:
+			closest = append(closest, wangTile.TileId)
+		}
+	}
+
+	return closest
+}
+
+// WangId returns the wang id of the tile with raw gid rawId, or false if it
+// isn't one of the set's tiles. Flipped tiles aren't either, as their
+// edges and corners have moved.
+func (r *Resolver) WangId(rawId uint32) (tilemaps.WangId, bool) {
+	gid, flip := tilemaps.DecodeGID(rawId)
+	if flip != 0 {
+		return tilemaps.WangId{}, false
+	}
+
+	wangId, exists := r.wangIds[gid-r.firstGid]
+	return wangId, exists
+}
+
+// Read fills terrain in from the tiles of the set already placed on the
+// tile layer named layerName, e.g. to edit a map's terrain at runtime.
+func (r *Resolver) Read(tileMap *tilemaps.TileMapJSON, layerName string, terrain *Terrain) error {
+	layer := tileMap.Layer(layerName)
+	if layer == nil || layer.Type != tilemaps.TileLayer {
+		return fmt.Errorf("no tile layer named %q", layerName)
+	}
+
+	layer.EachTile(func(x, y int, rawId uint32) {
+		if wangId, ok := r.WangId(rawId); ok {
+			terrain.SetWangId(x, y, wangId)
+		}
+	})
+
+	return nil
+}
+
+// Apply places the tiles fitting terrain within area on the tile layer
+// named layerName. Tiles without any terrain are left as they are.
+func (r *Resolver) Apply(tileMap *tilemaps.TileMapJSON, layerName string, terrain *Terrain, area image.Rectangle) error {
+	area = area.Intersect(terrain.Bounds())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			rawId, ok := r.Resolve(terrain.WangId(x, y), x, y)
+			if !ok {
+				continue
+			}
+
+			if err := tileMap.SetTile(layerName, x, y, rawId); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Paint paints tile x, y of terrain with the wang color color and places
+// the tiles of it and its neighbours again, e.g. when the player digs a
+// hole.
+func (r *Resolver) Paint(tileMap *tilemaps.TileMapJSON, layerName string, terrain *Terrain, x, y, color int) error {
+	terrain.Paint(x, y, color)
+	return r.Apply(tileMap, layerName, terrain, image.Rect(x-1, y-1, x+2, y+2))
+}
@@ -0,0 +1,226 @@
+package autotiles
+
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// colors of the test wang sets
+const (
+	grass = 1
+	water = 2
+)
+
+// first gid of the tileset the test wang sets belong to
+const firstGid = 10
+
+// cornerSet returns a corner set of grass and water with a tile for each of
+// the 16 ways to mix them, whose id has a bit set for every water corner:
+// top right, bottom right, bottom left and top left.
+func cornerSet() *tilemaps.WangSetJSON {
+	wangSet := tilemaps.WangSetJSON{Name: "ground", Type: tilemaps.WangCorner}
+	for id := 0; id < 16; id++ {
+		wangId := tilemaps.WangId{}
+		for bit, part := range []int{tilemaps.WangTopRight, tilemaps.WangBottomRight, tilemaps.WangBottomLeft, tilemaps.WangTopLeft} {
+			wangId[part] = grass
+			if id&(1<<bit) != 0 {
+				wangId[part] = water
+			}
+		}
+		wangSet.WangTiles = append(wangSet.WangTiles, &tilemaps.WangTileJSON{TileId: id, WangId: wangId})
+	}
+	return &wangSet
+}
+
+func TestResolveMasks(t *testing.T) {
+	edgeSet := tilemaps.WangSetJSON{Type: tilemaps.WangEdge, WangTiles: []*tilemaps.WangTileJSON{
+		{TileId: 0, WangId: tilemaps.WangId{1, 0, 1, 0, 1, 0, 1, 0}},
+		{TileId: 1, WangId: tilemaps.WangId{2, 0, 1, 0, 1, 0, 1, 0}},
+	}}
+	mixedSet := tilemaps.WangSetJSON{Type: tilemaps.WangMixed, WangTiles: []*tilemaps.WangTileJSON{
+		{TileId: 0, WangId: tilemaps.WangId{1, 1, 1, 1, 1, 1, 1, 1}},
+		{TileId: 1, WangId: tilemaps.WangId{1, 2, 1, 1, 1, 1, 1, 1}},
+		{TileId: 2, WangId: tilemaps.WangId{2, 1, 1, 1, 1, 1, 1, 1}},
+	}}
+
+	tests := []struct {
+		name    string
+		wangSet *tilemaps.WangSetJSON
+		wangId  tilemaps.WangId
+		want    uint32
+	}{
+		// corner sets ignore edges, edge sets corners
+		{"corner", cornerSet(), tilemaps.WangId{2, 2, 2, 1, 2, 1, 2, 1}, firstGid + 1},
+		{"corner ignoring edges", cornerSet(), tilemaps.WangId{0, 1, 0, 2, 0, 2, 0, 2}, firstGid + 14},
+		{"edge", &edgeSet, tilemaps.WangId{2, 0, 1, 0, 1, 0, 1, 0}, firstGid + 1},
+		{"edge ignoring corners", &edgeSet, tilemaps.WangId{1, 2, 1, 2, 1, 2, 1, 2}, firstGid},
+		// mixed sets tell corners and edges apart
+		{"mixed corner", &mixedSet, tilemaps.WangId{1, 2, 1, 1, 1, 1, 1, 1}, firstGid + 1},
+		{"mixed edge", &mixedSet, tilemaps.WangId{2, 1, 1, 1, 1, 1, 1, 1}, firstGid + 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := NewResolver(test.wangSet, firstGid).Resolve(test.wangId, 0, 0)
+			if !ok || got != test.want {
+				t.Errorf("got %d, %t, want %d", got, ok, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveClosest(t *testing.T) {
+	// grass, water, and water in the top right corner only
+	wangSet := tilemaps.WangSetJSON{Type: tilemaps.WangCorner, WangTiles: []*tilemaps.WangTileJSON{
+		{TileId: 0, WangId: tilemaps.WangId{0, 1, 0, 1, 0, 1, 0, 1}},
+		{TileId: 1, WangId: tilemaps.WangId{0, 2, 0, 2, 0, 2, 0, 2}},
+		{TileId: 2, WangId: tilemaps.WangId{0, 2, 0, 1, 0, 1, 0, 1}},
+	}}
+	resolver := NewResolver(&wangSet, firstGid)
+
+	tests := []struct {
+		name   string
+		wangId tilemaps.WangId
+		want   []uint32
+	}{
+		{"one part off", tilemaps.WangId{0, 2, 0, 2, 0, 2, 0, 1}, []uint32{firstGid + 1}},
+		{"closest to a transition", tilemaps.WangId{0, 2, 0, 2, 0, 1, 0, 1}, []uint32{firstGid + 2}},
+		{"tied", tilemaps.WangId{0, 1, 0, 2, 0, 2, 0, 1}, []uint32{firstGid, firstGid + 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seen := make(map[uint32]bool)
+			for x := 0; x < 16; x++ {
+				got, ok := resolver.Resolve(test.wangId, x, 0)
+				if !ok {
+					t.Fatal("resolved nothing")
+				}
+
+				// the same position always gets the same tile
+				if again, _ := resolver.Resolve(test.wangId, x, 0); again != got {
+					t.Errorf("tile (%d, 0): got %d, then %d", x, got, again)
+				}
+				seen[got] = true
+			}
+
+			want := make(map[uint32]bool)
+			for _, gid := range test.want {
+				want[gid] = true
+			}
+			if !reflect.DeepEqual(seen, want) {
+				t.Errorf("got %v, want %v", seen, want)
+			}
+		})
+	}
+
+	if _, ok := resolver.Resolve(tilemaps.WangId{1, 0, 1, 0, 1, 0, 1, 0}, 0, 0); ok {
+		t.Error("resolved a wang id with only parts the set doesn't use")
+	}
+}
+
+// newTestMap returns an orthogonal map with a single empty tile layer.
+func newTestMap(width, height int, props properties.Properties) *tilemaps.TileMapJSON {
+	return &tilemaps.TileMapJSON{
+		Height: height,
+		Layers: []tilemaps.TileMapLayerJSON{{
+			Data:       make([]uint32, width*height),
+			Height:     height,
+			Name:       "ground",
+			Properties: props,
+			Type:       tilemaps.TileLayer,
+			Width:      width,
+		}},
+		Orientation: tilemaps.Orthogonal,
+		TileHeight:  16,
+		TileWidth:   16,
+		Width:       width,
+	}
+}
+
+func TestReadApplyRoundTrip(t *testing.T) {
+	resolver := NewResolver(cornerSet(), firstGid)
+
+	terrain := NewTerrain(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			terrain.Paint(x, y, grass)
+		}
+	}
+
+	tileMap := newTestMap(4, 4, nil)
+	if err := resolver.Paint(tileMap, "ground", terrain, 1, 1, water); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolver.Apply(tileMap, "ground", terrain, terrain.Bounds()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the water tile, and its neighbours with water in the corners they
+	// share with it
+	want := []uint32{
+		firstGid + 2, firstGid + 6, firstGid + 4, firstGid,
+		firstGid + 3, firstGid + 15, firstGid + 12, firstGid,
+		firstGid + 1, firstGid + 9, firstGid + 8, firstGid,
+		firstGid, firstGid, firstGid, firstGid,
+	}
+	if got := tileMap.Layer("ground").Data; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	read := NewTerrain(terrain.Bounds())
+	if err := resolver.Read(tileMap, "ground", read); err != nil {
+		t.Fatal(err)
+	}
+
+	again := newTestMap(4, 4, nil)
+	if err := resolver.Apply(again, "ground", read, read.Bounds()); err != nil {
+		t.Fatal(err)
+	}
+	if got := again.Layer("ground").Data; !reflect.DeepEqual(got, want) {
+		t.Errorf("applying the terrain read back: got %v, want %v", got, want)
+	}
+}
+
+// wangSets finds the single wang set of the tests, see WangSets.
+type wangSets struct {
+	wangSet *tilemaps.WangSetJSON
+}
+
+func (w wangSets) WangSet(name string) (*tilemaps.WangSetJSON, int, error) {
+	if name != w.wangSet.Name {
+		return nil, 0, fmt.Errorf("no wang set named %q", name)
+	}
+	return w.wangSet, firstGid, nil
+}
+
+func TestResolveLayers(t *testing.T) {
+	sets := wangSets{cornerSet()}
+	props := properties.Properties{{Name: WangSetProperty, Type: properties.StringType, Value: "ground"}}
+
+	// whole tiles of water next to whole tiles of grass, and a tile of
+	// another tileset
+	tileMap := newTestMap(3, 1, props)
+	copy(tileMap.Layer("ground").Data, []uint32{firstGid + 15, firstGid, 99})
+
+	if err := ResolveLayers(tileMap, sets); err != nil {
+		t.Fatal(err)
+	}
+
+	// tiles read later win the corners they share, so the water tile takes
+	// the grass ones of its neighbour
+	want := []uint32{firstGid + 12, firstGid, 99}
+	if got := tileMap.Layer("ground").Data; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	props[0].Value = "missing"
+	if err := ResolveLayers(tileMap, sets); err == nil {
+		t.Error("resolved a layer naming a missing wang set")
+	}
+}
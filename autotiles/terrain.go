@@ -0,0 +1,95 @@
+package autotiles
+
+import (
+	"image"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// where each part of a wang id lies in the 3 x 3 block of a tile in a
+// terrain's grid
+var partOffsets = [8]image.Point{
+	tilemaps.WangTop:         {1, 0},
+	tilemaps.WangTopRight:    {2, 0},
+	tilemaps.WangRight:       {2, 1},
+	tilemaps.WangBottomRight: {2, 2},
+	tilemaps.WangBottom:      {1, 2},
+	tilemaps.WangBottomLeft:  {0, 2},
+	tilemaps.WangLeft:        {0, 1},
+	tilemaps.WangTopLeft:     {0, 0},
+}
+
+// Terrain holds the wang colors of the edges and corners of an area of
+// tiles. Neighbouring tiles share their edges and corners, so painting one
+// tile changes the wang ids of the tiles around it too, which is what lets
+// them blend into each other.
+type Terrain struct {
+	bounds image.Rectangle // in tiles
+	colors []int           // row by row, with corners at even and edges at mixed positions
+	stride int
+}
+
+func NewTerrain(bounds image.Rectangle) *Terrain {
+	stride := 2*bounds.Dx() + 1
+	return &Terrain{
+		bounds: bounds,
+		colors: make([]int, stride*(2*bounds.Dy()+1)),
+		stride: stride,
+	}
+}
+
+// Bounds returns the area of tiles the terrain covers.
+func (t *Terrain) Bounds() image.Rectangle {
+	return t.bounds
+}
+
+// Paint gives every edge and corner of tile x, y the wang color color.
+// Tiles outside the terrain are ignored.
+func (t *Terrain) Paint(x, y, color int) {
+	for part := range partOffsets {
+		t.Set(x, y, part, color)
+	}
+}
+
+// Set gives one part of tile x, y, e.g. tilemaps.WangTopLeft, the wang
+// color color.
+func (t *Terrain) Set(x, y, part, color int) {
+	if idx, ok := t.index(x, y, part); ok {
+		t.colors[idx] = color
+	}
+}
+
+// WangId returns the wang colors of the edges and corners of tile x, y, all
+// 0 outside the terrain.
+func (t *Terrain) WangId(x, y int) tilemaps.WangId {
+	wangId := tilemaps.WangId{}
+	for part := range partOffsets {
+		if idx, ok := t.index(x, y, part); ok {
+			wangId[part] = t.colors[idx]
+		}
+	}
+	return wangId
+}
+
+// SetWangId gives the edges and corners of tile x, y the colors of wangId.
+// Parts it leaves at 0 are left unchanged, so tiles of corner sets don't
+// clear the edges of their neighbours and the other way around.
+func (t *Terrain) SetWangId(x, y int, wangId tilemaps.WangId) {
+	for part, color := range wangId {
+		if color != 0 {
+			t.Set(x, y, part, color)
+		}
+	}
+}
+
+func (t *Terrain) index(x, y, part int) (int, bool) {
+	if !image.Pt(x, y).In(t.bounds) {
+		return 0, false
+	}
+
+	offset := partOffsets[part]
+	gridX := 2*(x-t.bounds.Min.X) + offset.X
+	gridY := 2*(y-t.bounds.Min.Y) + offset.Y
+
+	return gridY*t.stride + gridX, true
+}
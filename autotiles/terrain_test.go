@@ -0,0 +1,58 @@
+package autotiles
+
+import (
+	"image"
+	"testing"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+func TestTerrainParts(t *testing.T) {
+	// bounds not at the origin, to catch indexing relative to it
+	terrain := NewTerrain(image.Rect(-1, -1, 2, 2))
+	terrain.Paint(0, 0, 2)
+
+	tests := []struct {
+		x, y int
+		want tilemaps.WangId
+	}{
+		{0, 0, tilemaps.WangId{2, 2, 2, 2, 2, 2, 2, 2}},
+		// neighbours share the edges and corners they touch
+		{-1, -1, tilemaps.WangId{tilemaps.WangBottomRight: 2}},
+		{0, -1, tilemaps.WangId{tilemaps.WangBottomRight: 2, tilemaps.WangBottom: 2, tilemaps.WangBottomLeft: 2}},
+		{1, -1, tilemaps.WangId{tilemaps.WangBottomLeft: 2}},
+		{1, 0, tilemaps.WangId{tilemaps.WangTopLeft: 2, tilemaps.WangLeft: 2, tilemaps.WangBottomLeft: 2}},
+		{1, 1, tilemaps.WangId{tilemaps.WangTopLeft: 2}},
+		{0, 1, tilemaps.WangId{tilemaps.WangTopRight: 2, tilemaps.WangTop: 2, tilemaps.WangTopLeft: 2}},
+		{-1, 1, tilemaps.WangId{tilemaps.WangTopRight: 2}},
+		{-1, 0, tilemaps.WangId{tilemaps.WangTopRight: 2, tilemaps.WangRight: 2, tilemaps.WangBottomRight: 2}},
+		// outside the terrain
+		{2, 0, tilemaps.WangId{}},
+	}
+
+	for _, test := range tests {
+		if got := terrain.WangId(test.x, test.y); got != test.want {
+			t.Errorf("tile (%d, %d): got %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+}
+
+func TestTerrainSetWangId(t *testing.T) {
+	terrain := NewTerrain(image.Rect(0, 0, 2, 1))
+	terrain.Paint(0, 0, 1)
+
+	// the zero edges of a corner tile leave the edges it shares alone
+	terrain.SetWangId(1, 0, tilemaps.WangId{tilemaps.WangTopLeft: 2, tilemaps.WangBottomLeft: 2})
+
+	want := tilemaps.WangId{1, 2, 1, 2, 1, 1, 1, 1}
+	if got := terrain.WangId(0, 0); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// parts outside the terrain are ignored
+	terrain.Paint(5, 5, 2)
+	terrain.Set(-1, 0, tilemaps.WangRight, 2)
+	if got := terrain.WangId(0, 0); got != want {
+		t.Errorf("after painting outside: got %v, want %v", got, want)
+	}
+}
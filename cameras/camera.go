@@ -1,6 +1,9 @@
 package cameras
 
-import "math"
+import (
+	"image"
+	"math"
+)
 
 type Camera struct {
 	X float64
@@ -19,10 +22,12 @@ func (c *Camera) FollowTarget(targetX, targetY, screenWidth, screenHeight float6
 	c.Y = -targetY + screenHeight/2.0
 }
 
-func (c *Camera) Constrain(tilemapWidthPixels, tilemapHeightPixels, screenWidth, screenHeight float64) {
-	c.X = math.Min(c.X, 0.0)
-	c.Y = math.Min(c.Y, 0.0)
+// Constrain keeps the view inside the map's pixel bounds. Infinite maps can
+// extend into negative coordinates, so the bounds don't have to start at 0.
+func (c *Camera) Constrain(bounds image.Rectangle, screenWidth, screenHeight float64) {
+	c.X = math.Min(c.X, -float64(bounds.Min.X))
+	c.Y = math.Min(c.Y, -float64(bounds.Min.Y))
 
-	c.X = math.Max(c.X, screenWidth-tilemapWidthPixels)
-	c.Y = math.Max(c.Y, screenHeight-tilemapHeightPixels)
+	c.X = math.Max(c.X, screenWidth-float64(bounds.Max.X))
+	c.Y = math.Max(c.Y, screenHeight-float64(bounds.Max.Y))
 }
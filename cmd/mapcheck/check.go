@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"path"
+
+	"github.com/ev-the-dev/rpg-tutorial/spawns"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// report collects the problems found in one map.
+type report struct {
+	problems []string
+}
+
+func (r *report) add(format string, args ...any) {
+	r.problems = append(r.problems, fmt.Sprintf(format, args...))
+}
+
+// checkMap loads the map at mapPath in fsys with its tilesets and reports
+// whatever would break the game or be wrong in it.
+func checkMap(fsys fs.FS, mapPath string) *report {
+	r := report{problems: make([]string, 0)}
+
+	// malformed files, unsupported encodings and compressions, and data
+	// that doesn't decode all fail here
+	tileMap, err := tilemaps.NewTileMap(fsys, mapPath)
+	if err != nil {
+		r.add("%v", err)
+		return &r
+	}
+
+	tiles := newTileTable(fsys, mapPath, tileMap, &r)
+
+	for _, layer := range tileMap.FlatLayers() {
+		switch layer.Type {
+		case tilemaps.ImageLayer:
+			checkLayerImage(fsys, mapPath, &layer, &r)
+		case tilemaps.TileLayer:
+			checkLayerSize(tileMap, &layer, &r)
+			checkGids(&layer, tiles, &r)
+		}
+	}
+
+	checkSpawnClasses(tileMap, &r)
+	checkWarps(fsys, mapPath, tileMap, &r)
+
+	colliders, err := tileMap.Colliders(tiles)
+	if err != nil {
+		r.add("%v", err)
+		return &r
+	}
+	checkReachable(tileMap, colliders, &r)
+
+	return &r
+}
+
+// checkLayerImage reports image layers whose image is missing or isn't one.
+func checkLayerImage(fsys fs.FS, mapPath string, layer *tilemaps.TileMapLayerJSON, r *report) {
+	if layer.Image == "" {
+		return
+	}
+
+	if _, err := imageSize(fsys, tilemaps.ResolvePath(path.Dir(mapPath), layer.Image)); err != nil {
+		r.add("image layer %q: %v", layer.Name, err)
+	}
+}
+
+// checkLayerSize reports tile layers whose data doesn't fit their size, or
+// whose size isn't the map's.
+func checkLayerSize(tileMap *tilemaps.TileMapJSON, layer *tilemaps.TileMapLayerJSON, r *report) {
+	if tileMap.Infinite {
+		for _, chunk := range layer.Chunks {
+			if len(chunk.Data) != chunk.Width*chunk.Height {
+				r.add("layer %q chunk (%d, %d): %d tiles of data for %dx%d tiles", layer.Name, chunk.X, chunk.Y, len(chunk.Data), chunk.Width, chunk.Height)
+			}
+		}
+		return
+	}
+
+	if layer.Width != tileMap.Width || layer.Height != tileMap.Height {
+		r.add("layer %q: is %dx%d tiles, the map %dx%d", layer.Name, layer.Width, layer.Height, tileMap.Width, tileMap.Height)
+	}
+	if len(layer.Data) != layer.Width*layer.Height {
+		r.add("layer %q: %d tiles of data for %dx%d tiles", layer.Name, len(layer.Data), layer.Width, layer.Height)
+	}
+}
+
+// checkGids reports every gid of a layer no tileset has a tile for, once,
+// with where it's first used and how often.
+func checkGids(layer *tilemaps.TileMapLayerJSON, tiles *tileTable, r *report) {
+	type use struct {
+		count int
+		err   error
+		first image.Point
+	}
+
+	uses := make(map[int]*use)
+	order := make([]int, 0)
+	layer.EachTile(func(x, y int, rawId uint32) {
+		gid, _ := tilemaps.DecodeGID(rawId)
+		if existing, exists := uses[gid]; exists {
+			existing.count++
+			return
+		}
+
+		err := tiles.unknown(gid)
+		if err == nil {
+			return
+		}
+
+		uses[gid] = &use{count: 1, err: err, first: image.Pt(x, y)}
+		order = append(order, gid)
+	})
+
+	for _, gid := range order {
+		u := uses[gid]
+		r.add("layer %q tile (%d, %d): %v, used %d times", layer.Name, u.first.X, u.first.Y, u.err, u.count)
+	}
+}
+
+// checkSpawnClasses reports spawn points of classes the game can't spawn.
+func checkSpawnClasses(tileMap *tilemaps.TileMapJSON, r *report) {
+	known := make(map[string]bool)
+	for _, class := range spawns.Classes {
+		known[class] = true
+	}
+
+	for _, point := range tileMap.SpawnPoints() {
+		if !known[point.ClassName()] {
+			r.add("object %d: unknown spawn class %q", point.Id, point.ClassName())
+		}
+	}
+}
+
+// checkWarps reports warps that are malformed, lead to maps that don't load
+// or to player spawn points their map doesn't have.
+func checkWarps(fsys fs.FS, mapPath string, tileMap *tilemaps.TileMapJSON, r *report) {
+	warps, err := tileMap.Warps()
+	if err != nil {
+		r.add("%v", err)
+		return
+	}
+
+	for _, warp := range warps {
+		target, err := tilemaps.NewTileMap(fsys, tilemaps.ResolvePath(path.Dir(mapPath), warp.Map))
+		if err != nil {
+			r.add("warp to %s: %v", warp.Map, err)
+			continue
+		}
+
+		if warp.Spawn != "" && !hasPlayerSpawn(target, warp.Spawn) {
+			r.add("warp to %s: no player spawn named %q", warp.Map, warp.Spawn)
+		}
+	}
+}
+
+func hasPlayerSpawn(tileMap *tilemaps.TileMapJSON, name string) bool {
+	for _, point := range tileMap.SpawnPoints() {
+		if point.ClassName() == spawns.PlayerSpawn && point.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// mapJSON returns a 4x4 map of 16 pixel tiles using the floor tileset.
+func mapJSON(layers ...string) string {
+	return `{
+		"width": 4, "height": 4, "tilewidth": 16, "tileheight": 16,
+		"layers": [` + strings.Join(layers, ",") + `],
+		"tilesets": [{"firstgid": 1, "source": "floor.json"}]
+	}`
+}
+
+// tileLayer returns a tile layer named ground of the given size.
+func tileLayer(width, height int, data ...int) string {
+	text := make([]string, len(data))
+	for i, gid := range data {
+		text[i] = fmt.Sprint(gid)
+	}
+	return fmt.Sprintf(`{"type": "tilelayer", "name": "ground", "width": %d, "height": %d, "data": [%s]}`, width, height, strings.Join(text, ","))
+}
+
+func objectLayer(objects ...string) string {
+	return `{"type": "objectgroup", "name": "spawns", "objects": [` + strings.Join(objects, ",") + `]}`
+}
+
+func point(id int, class, name string, x, y int) string {
+	return fmt.Sprintf(`{"id": %d, "class": %q, "name": %q, "point": true, "x": %d, "y": %d}`, id, class, name, x, y)
+}
+
+func warp(id int, mapPath, spawn string) string {
+	return fmt.Sprintf(`{"id": %d, "class": "warp", "x": 48, "y": 48, "width": 16, "height": 16, "properties": [
+		{"name": "map", "type": "string", "value": %q},
+		{"name": "spawn", "type": "string", "value": %q}
+	]}`, id, mapPath, spawn)
+}
+
+// floor is a 4x4 map of plain floor, gid 1. Gid 4 is a wall.
+var floor = []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+
+func pngData(w, h int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// baseFiles are the files every map of the tests can use, by path in the
+// assets file system.
+var baseFiles = map[string]string{
+	"images/floor.png": pngData(32, 32),
+	"maps/floor.json": `{
+		"tilewidth": 16, "tileheight": 16, "tilecount": 4, "columns": 2, "image": "../images/floor.png",
+		"tiles": [{"id": 3, "objectgroup": {"objects": [{"id": 1, "x": 0, "y": 0, "width": 16, "height": 16}]}}]
+	}`,
+	"maps/cave.json": mapJSON(tileLayer(4, 4, floor...), objectLayer(point(1, "player_spawn", "entrance", 0, 0))),
+}
+
+// checkFiles checks the map at mapPath in a file system holding baseFiles
+// and files.
+func checkFiles(t *testing.T, files map[string]string, mapPath string) []string {
+	t.Helper()
+
+	fsys := fstest.MapFS{}
+	for _, fileSet := range []map[string]string{baseFiles, files} {
+		for name, contents := range fileSet {
+			fsys[name] = &fstest.MapFile{Data: []byte(contents)}
+		}
+	}
+
+	return checkMap(fsys, mapPath).problems
+}
+
+func TestCheckMap(t *testing.T) {
+	walled := append([]int{}, floor...)
+	for y := 0; y < 4; y++ {
+		walled[y*4+2] = 4
+	}
+
+	stuck := append([]int{}, floor...)
+	stuck[2*4+2] = 4
+
+	tests := []struct {
+		name    string
+		tileMap string
+		want    []string // a part of each problem, in the order they're found
+	}{
+		{
+			name: "valid",
+			tileMap: mapJSON(
+				`{"type": "imagelayer", "name": "sky", "image": "../images/floor.png"}`,
+				tileLayer(4, 4, floor...),
+				objectLayer(point(1, "player_spawn", "gate", 0, 0), point(2, "potion.heart", "", 48, 0), warp(3, "cave.json", "entrance")),
+			),
+		},
+		{
+			name:    "malformed map",
+			tileMap: `{"width": 4,`,
+			want:    []string{"unexpected end of JSON input"},
+		},
+		{
+			name:    "missing tileset",
+			tileMap: strings.Replace(mapJSON(tileLayer(4, 4, floor...)), "floor.json", "missing.json", 1),
+			want:    []string{"tileset 0: open maps/missing.json"},
+		},
+		{
+			name:    "missing image layer image",
+			tileMap: mapJSON(`{"type": "imagelayer", "name": "sky", "image": "../images/sky.png"}`, tileLayer(4, 4, floor...)),
+			want:    []string{`image layer "sky": open images/sky.png`},
+		},
+		{
+			name:    "layer size",
+			tileMap: mapJSON(tileLayer(3, 4, floor[:12]...)),
+			want:    []string{`layer "ground": is 3x4 tiles, the map 4x4`},
+		},
+		{
+			name:    "data size",
+			tileMap: mapJSON(tileLayer(4, 4, floor[:15]...)),
+			want:    []string{`layer "ground": 15 tiles of data for 4x4 tiles`},
+		},
+		{
+			name:    "unknown gid",
+			tileMap: mapJSON(tileLayer(4, 4, append([]int{9, 9}, floor[2:]...)...)),
+			want:    []string{`layer "ground" tile (0, 0): gid 9: past the last tile of its tileset, used 2 times`},
+		},
+		{
+			name:    "unknown spawn class",
+			tileMap: mapJSON(tileLayer(4, 4, floor...), objectLayer(point(1, "enemy.dragon", "", 0, 0))),
+			want:    []string{`object 1: unknown spawn class "enemy.dragon"`},
+		},
+		{
+			name:    "spawn outside the map",
+			tileMap: mapJSON(tileLayer(4, 4, floor...), objectLayer(point(1, "player_spawn", "", 80, 0))),
+			want:    []string{"object 1 (player_spawn): at (80, 0), outside the map"},
+		},
+		{
+			name:    "spawn in a collider",
+			tileMap: mapJSON(tileLayer(4, 4, stuck...), objectLayer(point(1, "player_spawn", "", 0, 0), point(2, "potion.heart", "", 32, 32))),
+			want:    []string{"object 2 (potion.heart): at (32, 32), stuck in a collider"},
+		},
+		{
+			name:    "unreachable spawn",
+			tileMap: mapJSON(tileLayer(4, 4, walled...), objectLayer(point(1, "player_spawn", "", 0, 0), point(2, "potion.heart", "", 48, 0))),
+			want:    []string{"object 2 (potion.heart): at (48, 0), can't be reached from any player spawn"},
+		},
+		{
+			name:    "warp to a missing map",
+			tileMap: mapJSON(tileLayer(4, 4, floor...), objectLayer(warp(1, "attic.json", ""))),
+			want:    []string{"warp to attic.json: open maps/attic.json"},
+		},
+		{
+			name:    "warp to a missing spawn",
+			tileMap: mapJSON(tileLayer(4, 4, floor...), objectLayer(warp(1, "cave.json", "exit"))),
+			want:    []string{`warp to cave.json: no player spawn named "exit"`},
+		},
+		{
+			name:    "warp without a map",
+			tileMap: mapJSON(tileLayer(4, 4, floor...), objectLayer(warp(1, "", ""))),
+			want:    []string{`layer "spawns" warp 1: has no map property`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := checkFiles(t, map[string]string{"maps/town.json": test.tileMap}, "maps/town.json")
+
+			if len(problems) != len(test.want) {
+				t.Fatalf("got problems %q, want %d", problems, len(test.want))
+			}
+			for i, want := range test.want {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("got problem %q, want it to contain %q", problems[i], want)
+				}
+			}
+		})
+	}
+}
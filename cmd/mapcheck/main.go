@@ -0,0 +1,61 @@
+// Command mapcheck loads maps together with their tilesets and reports what
+// would break the game or be wrong in it: unknown gids, missing tileset and
+// image files, layers whose size doesn't fit, encodings the game can't
+// read, spawn points of unknown classes, spawn points that can't be reached
+// and warps to maps or spawn points that don't exist. It exits with status
+// 1 if it found any problem, so it can run in CI.
+//
+// Usage:
+//
+//	mapcheck [-assets dir] [map ...]
+//
+// Maps are given by their path in the assets directory, e.g.
+// maps/spawn.json. Without any it checks every map in the maps directory.
+// It doesn't need a display.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+func main() {
+	assetsDir := flag.String("assets", "assets", "directory the maps and everything they refer to are in")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-assets dir] [map ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	fsys := os.DirFS(*assetsDir)
+
+	mapPaths := flag.Args()
+	if len(mapPaths) == 0 {
+		for _, pattern := range []string{"maps/*.json", "maps/*.tmx"} {
+			matches, err := fs.Glob(fsys, pattern)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			mapPaths = append(mapPaths, matches...)
+		}
+	}
+
+	failed := false
+	for _, mapPath := range mapPaths {
+		r := checkMap(fsys, mapPath)
+		for _, problem := range r.problems {
+			fmt.Printf("%s: %s\n", mapPath, problem)
+		}
+
+		if len(r.problems) > 0 {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
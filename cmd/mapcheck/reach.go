@@ -0,0 +1,120 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/ev-the-dev/rpg-tutorial/constants"
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+	"github.com/ev-the-dev/rpg-tutorial/spawns"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// how far apart the positions the walkable area is sampled at are, in
+// pixels
+const reachStep = constants.Tilesize / 2
+
+// checkReachable reports spawn points whose sprites would start outside the
+// map or stuck in a collider, and the ones the player can't walk to from
+// any player spawn. Walking is sampled every reachStep pixels, so gaps much
+// narrower than a sprite may be missed. Maps of a world can be entered from
+// their neighbours, so without player spawns only the first two are
+// checked.
+func checkReachable(tileMap *tilemaps.TileMapJSON, colliders []image.Rectangle, r *report) {
+	bounds := tileMap.PixelBounds()
+	free := func(pos image.Point) bool {
+		sprite := image.Rect(pos.X, pos.Y, pos.X+constants.Tilesize, pos.Y+constants.Tilesize)
+		if !sprite.In(bounds) {
+			return false
+		}
+
+		for _, collider := range colliders {
+			if collider.Overlaps(sprite) {
+				return false
+			}
+		}
+		return true
+	}
+
+	points := tileMap.SpawnPoints()
+	starts := make([]image.Point, 0)
+	placeable := make([]*objects.ObjectJSON, 0)
+	for _, point := range points {
+		pos := spritePosition(point)
+		switch {
+		case !pos.In(bounds):
+			r.add("object %d (%s): at (%g, %g), outside the map", point.Id, point.ClassName(), point.X, point.Y)
+		case !free(pos):
+			r.add("object %d (%s): at (%g, %g), stuck in a collider", point.Id, point.ClassName(), point.X, point.Y)
+		case point.ClassName() == spawns.PlayerSpawn:
+			starts = append(starts, pos)
+		default:
+			placeable = append(placeable, point)
+		}
+	}
+
+	if len(starts) == 0 {
+		return
+	}
+
+	walkable := walk(starts, free)
+	for _, point := range placeable {
+		if !nearAny(spritePosition(point), walkable) {
+			r.add("object %d (%s): at (%g, %g), can't be reached from any player spawn", point.Id, point.ClassName(), point.X, point.Y)
+		}
+	}
+}
+
+// spritePosition returns where the top left corner of the sprite spawned at
+// point is.
+func spritePosition(point *objects.ObjectJSON) image.Point {
+	return image.Pt(int(math.Floor(point.X)), int(math.Floor(point.Y)))
+}
+
+// walk returns every position free lets a sprite move to from starts, in
+// steps of reachStep pixels.
+func walk(starts []image.Point, free func(image.Point) bool) map[image.Point]bool {
+	steps := []image.Point{{reachStep, 0}, {-reachStep, 0}, {0, reachStep}, {0, -reachStep}}
+
+	walkable := make(map[image.Point]bool)
+	queue := make([]image.Point, 0, len(starts))
+	for _, start := range starts {
+		walkable[start] = true
+		queue = append(queue, start)
+	}
+
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+
+		for _, step := range steps {
+			next := pos.Add(step)
+			if walkable[next] || !free(next) {
+				continue
+			}
+
+			walkable[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return walkable
+}
+
+// nearAny reports whether a walkable position is less than a step away from
+// pos on both axes.
+func nearAny(pos image.Point, walkable map[image.Point]bool) bool {
+	for walked := range walkable {
+		if abs(walked.X-pos.X) < reachStep && abs(walked.Y-pos.Y) < reachStep {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/png"
+	"io/fs"
+	"sort"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// tileInfo is what the game needs of a tile without drawing it.
+type tileInfo struct {
+	colliders []image.Rectangle
+	size      image.Point
+}
+
+// tileTable knows the tiles of a map's tilesets by gid, read from their
+// files and the headers of their images, so it works without a display.
+type tileTable struct {
+	broken    map[int]bool // first gids of the tilesets that failed to load
+	firstGids []int
+	tiles     map[int]*tileInfo
+}
+
+// newTileTable reads the tilesets of tileMap, the map at mapPath in fsys,
+// reporting the ones that are broken or whose images are missing.
+func newTileTable(fsys fs.FS, mapPath string, tileMap *tilemaps.TileMapJSON, r *report) *tileTable {
+	table := tileTable{
+		broken:    make(map[int]bool),
+		firstGids: make([]int, 0),
+		tiles:     make(map[int]*tileInfo),
+	}
+
+	for i, ref := range tileMap.Tilesets {
+		if ref.FirstGid <= 0 {
+			r.add("tileset %d: firstgid %d is not positive", i, ref.FirstGid)
+			continue
+		}
+
+		table.firstGids = append(table.firstGids, ref.FirstGid)
+
+		tilesetJson, dir, err := ref.Load(fsys, mapPath)
+		if err != nil {
+			r.add("tileset %d: %v", i, err)
+			table.broken[ref.FirstGid] = true
+			continue
+		}
+
+		if err := table.addTileset(fsys, tilesetJson, dir, ref.FirstGid, r); err != nil {
+			r.add("tileset %d: %v", i, err)
+			table.broken[ref.FirstGid] = true
+		}
+	}
+
+	sort.Ints(table.firstGids)
+	return &table
+}
+
+func (t *tileTable) addTileset(fsys fs.FS, tilesetJson *tilemaps.TilesetJSON, dir string, firstGid int, r *report) error {
+	colliders := make(map[int][]image.Rectangle)
+	for _, tileJSON := range tilesetJson.Tiles {
+		colliders[tileJSON.Id] = tileJSON.Colliders()
+	}
+
+	if tilesetJson.IsCollection() {
+		for _, tileJSON := range tilesetJson.Tiles {
+			if tileJSON.Path == "" {
+				continue
+			}
+
+			size, err := imageSize(fsys, tilemaps.ResolvePath(dir, tileJSON.Path))
+			if err != nil {
+				r.add("tileset tile %d: %v", tileJSON.Id, err)
+				continue
+			}
+
+			t.tiles[firstGid+tileJSON.Id] = &tileInfo{colliders: colliders[tileJSON.Id], size: size}
+		}
+
+		return nil
+	}
+
+	if tilesetJson.Path == "" {
+		return fmt.Errorf("tileset has no image")
+	}
+	if tilesetJson.TileWidth <= 0 || tilesetJson.TileHeight <= 0 {
+		return fmt.Errorf("tile size %dx%d is not positive", tilesetJson.TileWidth, tilesetJson.TileHeight)
+	}
+
+	imgSize, err := imageSize(fsys, tilemaps.ResolvePath(dir, tilesetJson.Path))
+	if err != nil {
+		return err
+	}
+
+	_, tileCount := tilesetJson.Grid(imgSize.X, imgSize.Y)
+	for id := 0; id < tileCount; id++ {
+		t.tiles[firstGid+id] = &tileInfo{
+			colliders: colliders[id],
+			size:      image.Pt(tilesetJson.TileWidth, tilesetJson.TileHeight),
+		}
+	}
+
+	return nil
+}
+
+// unknown returns why gid belongs to no tile of a tileset that loaded, or
+// nil if it does. Gids of broken tilesets aren't unknown, as their tileset
+// was reported already.
+func (t *tileTable) unknown(gid int) error {
+	if _, exists := t.tiles[gid]; exists {
+		return nil
+	}
+
+	idx := sort.SearchInts(t.firstGids, gid+1) - 1
+	if idx < 0 {
+		return fmt.Errorf("gid %d: no tileset owns it", gid)
+	}
+	if t.broken[t.firstGids[idx]] {
+		return nil
+	}
+
+	return fmt.Errorf("gid %d: past the last tile of its tileset", gid)
+}
+
+// Colliders and Size make the table a tilemaps.TileShapes. Unknown tiles
+// have no size and no colliders, so they don't keep the colliders of the
+// rest of the map from being checked.
+func (t *tileTable) Colliders(gid int) ([]image.Rectangle, error) {
+	if info, exists := t.tiles[gid]; exists {
+		return info.colliders, nil
+	}
+	return nil, nil
+}
+
+func (t *tileTable) Size(gid int) (image.Point, error) {
+	if info, exists := t.tiles[gid]; exists {
+		return info.size, nil
+	}
+	return image.Point{}, nil
+}
+
+// imageSize reads the size of an image in fsys from its header.
+func imageSize(fsys fs.FS, path string) (image.Point, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return image.Point{}, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return image.Point{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return image.Pt(config.Width, config.Height), nil
+}
@@ -1,26 +1,30 @@
 package main
 
 import (
+	"github.com/ev-the-dev/rpg-tutorial/assets"
 	"github.com/ev-the-dev/rpg-tutorial/scenes"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Game struct {
 	activeSceneId scenes.SceneId
+	loadingScene  *scenes.LoadingScene
 	sceneMap      map[scenes.SceneId]scenes.Scene
 }
 
 func NewGame() *Game {
-	activeSceneId := scenes.StartSceneId
+	loadingScene := scenes.NewLoadingScene()
 	sceneMap := map[scenes.SceneId]scenes.Scene{
-		scenes.GameSceneId:  scenes.NewGameScene(),
-		scenes.PauseSceneId: scenes.NewPauseScene(),
-		scenes.StartSceneId: scenes.NewStartScene(),
+		scenes.GameSceneId:    scenes.NewGameScene(assets.NewManager(assets.FS), loadingScene),
+		scenes.LoadingSceneId: loadingScene,
+		scenes.PauseSceneId:   scenes.NewPauseScene(),
+		scenes.StartSceneId:   scenes.NewStartScene(),
 	}
-	sceneMap[activeSceneId].FirstLoad()
+	loadingScene.Load(sceneMap[scenes.StartSceneId], scenes.StartSceneId)
 
 	return &Game{
-		activeSceneId,
+		scenes.LoadingSceneId,
+		loadingScene,
 		sceneMap,
 	}
 }
@@ -35,9 +39,12 @@ func (g *Game) Update() error {
 	// If true, game switched scenes
 	if nextSceneId != g.activeSceneId {
 		nextScene := g.sceneMap[nextSceneId]
-		// check if scene loaded already
+		// scenes not loaded yet are loaded in the background, the loading
+		// scene switches to them once they are
 		if !nextScene.IsLoaded() {
-			nextScene.FirstLoad()
+			g.loadingScene.Load(nextScene, nextSceneId)
+			nextSceneId = scenes.LoadingSceneId
+			nextScene = g.loadingScene
 		}
 
 		nextScene.OnEnter()
@@ -2,7 +2,10 @@ module github.com/ev-the-dev/rpg-tutorial
 
 go 1.22.5
 
-require github.com/hajimehoshi/ebiten/v2 v2.8.3
+require (
+	github.com/hajimehoshi/ebiten/v2 v2.8.3
+	github.com/klauspost/compress v1.17.11
+)
 
 require (
 	github.com/ebitengine/gomobile v0.0.0-20240911145611-4856209ac325 // indirect
@@ -0,0 +1,115 @@
+package objects
+
+import (
+	"image"
+	"math"
+
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+type Shape uint8
+
+const (
+	Rectangle Shape = iota
+	Ellipse
+	Point
+	Polygon
+	Polyline
+)
+
+type PointJSON struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// ObjectJSON is a single object of a Tiled object layer. Polygon and
+// polyline points are relative to the object's X/Y position.
+type ObjectJSON struct {
+	Class      string                `json:"class"`
+	Ellipse    bool                  `json:"ellipse"`
+	Height     float64               `json:"height"`
+	Id         int                   `json:"id"`
+	Name       string                `json:"name"`
+	Point      bool                  `json:"point"`
+	Polygon    []*PointJSON          `json:"polygon"`
+	Polyline   []*PointJSON          `json:"polyline"`
+	Properties properties.Properties `json:"properties"`
+	Rotation   float64               `json:"rotation"` // degrees, clockwise around X/Y
+	Type       string                `json:"type"`
+	Visible    bool                  `json:"visible"`
+	Width      float64               `json:"width"`
+	X          float64               `json:"x"`
+	Y          float64               `json:"y"`
+}
+
+func (o *ObjectJSON) Shape() Shape {
+	switch {
+	case o.Point:
+		return Point
+	case o.Ellipse:
+		return Ellipse
+	case o.Polygon != nil:
+		return Polygon
+	case o.Polyline != nil:
+		return Polyline
+	}
+	return Rectangle
+}
+
+// HasArea reports whether the object encloses any space, which points and
+// polylines do not.
+func (o *ObjectJSON) HasArea() bool {
+	shape := o.Shape()
+	return shape != Point && shape != Polyline
+}
+
+// Tiled used to call an object's class its "type", and depending on the
+// version either key can be present.
+func (o *ObjectJSON) ClassName() string {
+	if o.Class != "" {
+		return o.Class
+	}
+	return o.Type
+}
+
+// Bounds returns the axis aligned bounding box of the object in map pixels,
+// taking its rotation into account.
+func (o *ObjectJSON) Bounds() image.Rectangle {
+	var points []*PointJSON
+	switch o.Shape() {
+	case Point:
+		return image.Rect(int(o.X), int(o.Y), int(o.X), int(o.Y))
+	case Polygon:
+		points = o.Polygon
+	case Polyline:
+		points = o.Polyline
+	default:
+		points = []*PointJSON{
+			{X: 0, Y: 0},
+			{X: o.Width, Y: 0},
+			{X: o.Width, Y: o.Height},
+			{X: 0, Y: o.Height},
+		}
+	}
+
+	sin, cos := math.Sincos(o.Rotation * math.Pi / 180.0)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		x := o.X + p.X*cos - p.Y*sin
+		y := o.Y + p.X*sin + p.Y*cos
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	if len(points) == 0 {
+		return image.Rectangle{}
+	}
+
+	return image.Rect(
+		int(math.Floor(minX)),
+		int(math.Floor(minY)),
+		int(math.Ceil(maxX)),
+		int(math.Ceil(maxY)),
+	)
+}
@@ -0,0 +1,95 @@
+package objects
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+type objectXML struct {
+	Class      string                `xml:"class,attr"`
+	Ellipse    *struct{}             `xml:"ellipse"`
+	Height     float64               `xml:"height,attr"`
+	Id         int                   `xml:"id,attr"`
+	Name       string                `xml:"name,attr"`
+	Point      *struct{}             `xml:"point"`
+	Polygon    *pointsXML            `xml:"polygon"`
+	Polyline   *pointsXML            `xml:"polyline"`
+	Properties properties.Properties `xml:"properties>property"`
+	Rotation   float64               `xml:"rotation,attr"`
+	Type       string                `xml:"type,attr"`
+	Visible    *bool                 `xml:"visible,attr"`
+	Width      float64               `xml:"width,attr"`
+	X          float64               `xml:"x,attr"`
+	Y          float64               `xml:"y,attr"`
+}
+
+type pointsXML struct {
+	Points string `xml:"points,attr"`
+}
+
+// UnmarshalXML reads an <object> element of a TMX or TSX file. Shapes are
+// child elements there, where JSON uses flags and point arrays.
+func (o *ObjectJSON) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var objXml objectXML
+	if err := d.DecodeElement(&objXml, &start); err != nil {
+		return err
+	}
+
+	*o = ObjectJSON{
+		Class:      objXml.Class,
+		Ellipse:    objXml.Ellipse != nil,
+		Height:     objXml.Height,
+		Id:         objXml.Id,
+		Name:       objXml.Name,
+		Point:      objXml.Point != nil,
+		Properties: objXml.Properties,
+		Rotation:   objXml.Rotation,
+		Type:       objXml.Type,
+		Visible:    objXml.Visible == nil || *objXml.Visible,
+		Width:      objXml.Width,
+		X:          objXml.X,
+		Y:          objXml.Y,
+	}
+
+	var err error
+	if objXml.Polygon != nil {
+		if o.Polygon, err = parsePoints(objXml.Polygon.Points); err != nil {
+			return fmt.Errorf("object %d polygon: %w", o.Id, err)
+		}
+	}
+	if objXml.Polyline != nil {
+		if o.Polyline, err = parsePoints(objXml.Polyline.Points); err != nil {
+			return fmt.Errorf("object %d polyline: %w", o.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// parsePoints reads a "x1,y1 x2,y2 ..." point list.
+func parsePoints(s string) ([]*PointJSON, error) {
+	points := make([]*PointJSON, 0)
+	for _, pair := range strings.Fields(s) {
+		xs, ys, found := strings.Cut(pair, ",")
+		if !found {
+			return nil, fmt.Errorf("malformed point %q", pair)
+		}
+
+		x, err := strconv.ParseFloat(xs, 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(ys, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		points = append(points, &PointJSON{X: x, Y: y})
+	}
+
+	return points, nil
+}
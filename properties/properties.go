@@ -0,0 +1,234 @@
+package properties
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// property types Tiled stores
+const (
+	BoolType   = "bool"
+	ClassType  = "class"
+	ColorType  = "color"
+	FileType   = "file"
+	FloatType  = "float"
+	IntType    = "int"
+	ObjectType = "object"
+	StringType = "string"
+)
+
+// PropertyJSON is a Tiled custom property. Numbers decode as float64, and
+// the members of class properties as Properties.
+type PropertyJSON struct {
+	Name         string `json:"name"`
+	PropertyType string `json:"propertytype"` // name of the custom type of class properties
+	Type         string `json:"type"`
+	Value        any    `json:"value"`
+}
+
+func (p *PropertyJSON) UnmarshalJSON(b []byte) error {
+	// alias drops the method so json.Unmarshal doesn't recurse into it
+	type alias PropertyJSON
+	if err := json.Unmarshal(b, (*alias)(p)); err != nil {
+		return err
+	}
+
+	if members, ok := p.Value.(map[string]any); ok {
+		p.Value = classMembers(members)
+	}
+	return nil
+}
+
+// classMembers converts the members of a class property, which JSON stores
+// as an object without their types, into Properties sorted by name.
+func classMembers(members map[string]any) Properties {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	props := make(Properties, 0, len(members))
+	for _, name := range names {
+		property := PropertyJSON{Name: name, Value: members[name]}
+		switch value := property.Value.(type) {
+		case bool:
+			property.Type = BoolType
+		case float64:
+			property.Type = FloatType
+		case map[string]any:
+			property.Type = ClassType
+			property.Value = classMembers(value)
+		default:
+			property.Type = StringType
+		}
+
+		props = append(props, &property)
+	}
+
+	return props
+}
+
+type Properties []*PropertyJSON
+
+func (p Properties) Get(name string) (*PropertyJSON, bool) {
+	for _, property := range p {
+		if property.Name == name {
+			return property, true
+		}
+	}
+	return nil, false
+}
+
+// Bool returns the named property, or def if it is missing or not a bool.
+func (p Properties) Bool(name string, def bool) bool {
+	property, ok := p.Get(name)
+	if !ok {
+		return def
+	}
+
+	value, ok := property.Value.(bool)
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// Float returns the named property, or def if it is missing or not a number.
+func (p Properties) Float(name string, def float64) float64 {
+	property, ok := p.Get(name)
+	if !ok {
+		return def
+	}
+
+	value, ok := property.Value.(float64)
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// Int returns the named property, or def if it is missing or not a number.
+func (p Properties) Int(name string, def int) int {
+	return int(p.Float(name, float64(def)))
+}
+
+// String returns the named property, or def if it is missing or not a string.
+func (p Properties) String(name string, def string) string {
+	property, ok := p.Get(name)
+	if !ok {
+		return def
+	}
+
+	value, ok := property.Value.(string)
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// Class returns the members of the named class property, or nil if it is
+// missing or not a class.
+func (p Properties) Class(name string) Properties {
+	property, ok := p.Get(name)
+	if !ok {
+		return nil
+	}
+
+	members, _ := property.Value.(Properties)
+	return members
+}
+
+// Color returns the named property, or def if it is missing, unset or not a
+// color.
+func (p Properties) Color(name string, def Color) Color {
+	value := p.String(name, "")
+	if value == "" {
+		return def
+	}
+
+	parsed, err := ParseColor(value)
+	if err != nil {
+		return def
+	}
+	return Color(parsed)
+}
+
+// File returns the path of the named file property, relative to the file
+// holding the property, or def if it is missing or not a string.
+func (p Properties) File(name string, def string) string {
+	return p.String(name, def)
+}
+
+// Object returns the id of the object the named object property refers to,
+// or 0 if it is missing or refers to none.
+func (p Properties) Object(name string) int {
+	return p.Int(name, 0)
+}
+
+// Color is a color stored the way Tiled writes them. It implements
+// color.Color.
+type Color color.NRGBA
+
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA(c).RGBA()
+}
+
+func (c *Color) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseColor(s)
+	if err != nil {
+		return err
+	}
+
+	*c = Color(parsed)
+	return nil
+}
+
+func (c *Color) UnmarshalXMLAttr(attr xml.Attr) error {
+	parsed, err := ParseColor(attr.Value)
+	if err != nil {
+		return err
+	}
+
+	*c = Color(parsed)
+	return nil
+}
+
+// ParseColor reads a color the way Tiled writes them, "#rrggbb" or
+// "#aarrggbb". The empty string is opaque white, which leaves what it tints
+// unchanged.
+func ParseColor(s string) (color.NRGBA, error) {
+	if s == "" {
+		return color.NRGBA{255, 255, 255, 255}, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("malformed color %q", s)
+	}
+
+	argb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("malformed color %q", s)
+	}
+
+	return color.NRGBA{
+		A: uint8(argb >> 24),
+		R: uint8(argb >> 16),
+		G: uint8(argb >> 8),
+		B: uint8(argb),
+	}, nil
+}
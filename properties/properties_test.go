@@ -0,0 +1,97 @@
+package properties
+
+import (
+	"encoding/json"
+	"image/color"
+	"testing"
+)
+
+const propsJSON = `[
+	{"name": "solid", "type": "bool", "value": true},
+	{"name": "speed", "type": "float", "value": 1.5},
+	{"name": "hp", "type": "int", "value": 3},
+	{"name": "title", "type": "string", "value": "Town"},
+	{"name": "tint", "type": "color", "value": "#80ff0000"},
+	{"name": "unset", "type": "color", "value": ""},
+	{"name": "door", "type": "object", "value": 12},
+	{"name": "loot", "type": "class", "propertytype": "Loot", "value": {"gold": 5, "rare": false}}
+]`
+
+func loadProps(t *testing.T) Properties {
+	t.Helper()
+
+	var props Properties
+	if err := json.Unmarshal([]byte(propsJSON), &props); err != nil {
+		t.Fatal(err)
+	}
+	return props
+}
+
+func TestAccessors(t *testing.T) {
+	props := loadProps(t)
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"bool", props.Bool("solid", false), true},
+		{"bool missing", props.Bool("missing", true), true},
+		{"bool mismatch", props.Bool("title", true), true},
+		{"float", props.Float("speed", 0), 1.5},
+		{"float missing", props.Float("missing", 2), 2.0},
+		{"float mismatch", props.Float("solid", 2), 2.0},
+		{"int", props.Int("hp", 0), 3},
+		{"int from float", props.Int("speed", 0), 1},
+		{"int missing", props.Int("missing", 7), 7},
+		{"int mismatch", props.Int("title", 7), 7},
+		{"string", props.String("title", ""), "Town"},
+		{"string missing", props.String("missing", "def"), "def"},
+		{"string mismatch", props.String("hp", "def"), "def"},
+		{"color", props.Color("tint", Color{}), Color{R: 255, A: 128}},
+		{"color missing", props.Color("missing", Color{B: 1}), Color{B: 1}},
+		{"color unset", props.Color("unset", Color{B: 1}), Color{B: 1}},
+		{"color mismatch", props.Color("hp", Color{B: 1}), Color{B: 1}},
+		{"color malformed", props.Color("title", Color{B: 1}), Color{B: 1}},
+		{"object", props.Object("door"), 12},
+		{"object missing", props.Object("missing"), 0},
+		{"class member", props.Class("loot").Int("gold", 0), 5},
+		{"class member bool", props.Class("loot").Bool("rare", true), false},
+		{"class missing", props.Class("missing") == nil, true},
+		{"class mismatch", props.Class("title") == nil, true},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    color.NRGBA
+		wantErr bool
+	}{
+		{"#aabbccdd", color.NRGBA{R: 0xbb, G: 0xcc, B: 0xdd, A: 0xaa}, false},
+		{"#00ff8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0x00}, false},
+		{"#ff8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}, false},
+		{"ff8000", color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}, false},
+		{"", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, false},
+		{"#fff", color.NRGBA{}, true},
+		{"#gg8000", color.NRGBA{}, true},
+		{"#ff800000ff", color.NRGBA{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseColor(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v", test.s, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: got %v, want %v", test.s, got, test.want)
+		}
+	}
+}
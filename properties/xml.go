@@ -0,0 +1,53 @@
+package properties
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+type propertyXML struct {
+	Members      Properties `xml:"properties>property"` // only in class properties
+	Name         string     `xml:"name,attr"`
+	PropertyType string     `xml:"propertytype,attr"`
+	Text         string     `xml:",chardata"`
+	Type         string     `xml:"type,attr"`
+	Value        *string    `xml:"value,attr"`
+}
+
+// UnmarshalXML reads a <property> element, converting its value to the
+// same Go type PropertyJSON.UnmarshalJSON produces for the JSON format.
+// Multiline strings are stored as the element's text instead of an
+// attribute.
+func (p *PropertyJSON) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var propXml propertyXML
+	if err := d.DecodeElement(&propXml, &start); err != nil {
+		return err
+	}
+
+	value := strings.TrimSpace(propXml.Text)
+	if propXml.Value != nil {
+		value = *propXml.Value
+	}
+
+	p.Name = propXml.Name
+	p.PropertyType = propXml.PropertyType
+	p.Type = propXml.Type
+	if p.Type == "" {
+		p.Type = StringType
+	}
+
+	var err error
+	switch p.Type {
+	case BoolType:
+		p.Value, err = strconv.ParseBool(value)
+	case ClassType:
+		p.Value = propXml.Members
+	case FloatType, IntType, ObjectType:
+		p.Value, err = strconv.ParseFloat(value, 64)
+	default:
+		p.Value = value
+	}
+
+	return err
+}
@@ -0,0 +1,196 @@
+package renderers
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+	"github.com/ev-the-dev/rpg-tutorial/tilesets"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ChunkSize is the width and height of a cached chunk, in tiles.
+const ChunkSize = 16
+
+type placedTile struct {
+	rawId uint32
+	x     int
+	y     int
+}
+
+type chunk struct {
+	animated  []placedTile    // drawn every frame on top of img
+	bounds    image.Rectangle // map pixels covered by all the chunk's tiles
+	dirty     bool
+	img       *ebiten.Image // the static tiles, nil if there are none
+	imgBounds image.Rectangle
+}
+
+// ChunkedLayer draws a tile layer from images of ChunkSize x ChunkSize tiles
+// that are only rendered again when one of their tiles changes. Animated
+// tiles are left out of them and drawn one by one instead.
+type ChunkedLayer struct {
+	chunks   map[image.Point]*chunk
+	keys     []image.Point // in drawing order, row by row
+	layer    *tilemaps.TileMapLayerJSON
+	tileMap  *tilemaps.TileMapJSON
+	tilesets *tilesets.Set
+}
+
+func NewChunkedLayer(tileMap *tilemaps.TileMapJSON, layer *tilemaps.TileMapLayerJSON, ts *tilesets.Set) *ChunkedLayer {
+	c := ChunkedLayer{
+		chunks:   make(map[image.Point]*chunk),
+		keys:     make([]image.Point, 0),
+		layer:    layer,
+		tileMap:  tileMap,
+		tilesets: ts,
+	}
+
+	bounds := layer.Bounds()
+	for y := floorDiv(bounds.Min.Y, ChunkSize); y*ChunkSize < bounds.Max.Y; y++ {
+		for x := floorDiv(bounds.Min.X, ChunkSize); x*ChunkSize < bounds.Max.X; x++ {
+			c.Invalidate(x*ChunkSize, y*ChunkSize)
+		}
+	}
+
+	return &c
+}
+
+// Invalidate marks the chunk holding tile x, y to be rendered again before
+// it's drawn next.
+func (c *ChunkedLayer) Invalidate(x, y int) {
+	key := image.Pt(floorDiv(x, ChunkSize), floorDiv(y, ChunkSize))
+	if existing, exists := c.chunks[key]; exists {
+		existing.dirty = true
+		return
+	}
+
+	c.chunks[key] = &chunk{dirty: true}
+
+	idx := sort.Search(len(c.keys), func(i int) bool {
+		return c.keys[i].Y > key.Y || c.keys[i].Y == key.Y && c.keys[i].X > key.X
+	})
+	c.keys = append(c.keys, image.Point{})
+	copy(c.keys[idx+1:], c.keys[idx:])
+	c.keys[idx] = key
+}
+
+// Deallocate frees the images of the rendered chunks, e.g. once the layer's
+// map is unloaded. Drawing the layer again renders them again.
+func (c *ChunkedLayer) Deallocate() {
+	for _, cached := range c.chunks {
+		if cached.img != nil {
+			cached.img.Deallocate()
+		}
+		*cached = chunk{dirty: true}
+	}
+}
+
+// Draw draws the layer to screen with opts, skipping the chunks outside of
+// it. opts.GeoM may only translate. ms is the game time animated tiles are
+// shown at. It returns how many images were drawn to screen.
+func (c *ChunkedLayer) Draw(screen *ebiten.Image, opts *ebiten.DrawImageOptions, ms int) int {
+	originX, originY := opts.GeoM.Apply(0, 0)
+	view := screen.Bounds().Sub(image.Pt(int(math.Floor(originX)), int(math.Floor(originY))))
+	view.Max = view.Max.Add(image.Pt(1, 1))
+
+	draws := 0
+	for _, key := range c.keys {
+		chunk := c.chunks[key]
+		if chunk.dirty {
+			c.render(key, chunk)
+		}
+
+		if !chunk.bounds.Overlaps(view) {
+			continue
+		}
+
+		if chunk.img != nil {
+			chunkOpts := *opts
+			chunkOpts.GeoM.Reset()
+			chunkOpts.GeoM.Translate(float64(chunk.imgBounds.Min.X), float64(chunk.imgBounds.Min.Y))
+			chunkOpts.GeoM.Concat(opts.GeoM)
+
+			screen.DrawImage(chunk.img, &chunkOpts)
+			draws++
+		}
+
+		for _, tile := range chunk.animated {
+			tileOpts := *opts
+			tileOpts.GeoM.Reset()
+			img, _ := PlaceTile(&tileOpts.GeoM, c.tileMap, c.tilesets, tile.x, tile.y, tile.rawId, ms)
+			if img == nil {
+				continue
+			}
+			tileOpts.GeoM.Concat(opts.GeoM)
+
+			screen.DrawImage(img, &tileOpts)
+			draws++
+		}
+	}
+
+	return draws
+}
+
+// render draws the static tiles of the chunk at key into its image and
+// collects its animated ones.
+func (c *ChunkedLayer) render(key image.Point, cached *chunk) {
+	type staticTile struct {
+		geoM ebiten.GeoM
+		img  *ebiten.Image
+	}
+
+	if cached.img != nil {
+		cached.img.Deallocate()
+	}
+	*cached = chunk{}
+
+	static := make([]staticTile, 0)
+	for y := key.Y * ChunkSize; y < (key.Y+1)*ChunkSize; y++ {
+		for x := key.X * ChunkSize; x < (key.X+1)*ChunkSize; x++ {
+			rawId := c.layer.Tile(x, y)
+			if rawId == 0 {
+				continue
+			}
+
+			tile := staticTile{}
+			img, bounds := PlaceTile(&tile.geoM, c.tileMap, c.tilesets, x, y, rawId, 0)
+			if img == nil {
+				continue
+			}
+
+			cached.bounds = cached.bounds.Union(bounds)
+
+			imgId, _ := tilemaps.DecodeGID(rawId)
+			if c.tilesets.Animated(imgId) {
+				cached.animated = append(cached.animated, placedTile{rawId: rawId, x: x, y: y})
+				continue
+			}
+
+			cached.imgBounds = cached.imgBounds.Union(bounds)
+			tile.img = img
+			static = append(static, tile)
+		}
+	}
+
+	if len(static) == 0 {
+		return
+	}
+
+	cached.img = ebiten.NewImage(cached.imgBounds.Dx(), cached.imgBounds.Dy())
+	for _, tile := range static {
+		opts := ebiten.DrawImageOptions{GeoM: tile.geoM}
+		opts.GeoM.Translate(-float64(cached.imgBounds.Min.X), -float64(cached.imgBounds.Min.Y))
+		cached.img.DrawImage(tile.img, &opts)
+	}
+}
+
+// floorDiv divides rounding towards negative infinity, so chunks of
+// infinite maps left of or above the origin don't share index 0.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
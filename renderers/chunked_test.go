@@ -0,0 +1,84 @@
+package renderers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ev-the-dev/rpg-tutorial/assets"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+	"github.com/ev-the-dev/rpg-tutorial/tilesets"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// loadSpawnMap loads the game's starting map from the assets directory.
+func loadSpawnMap(b *testing.B) (*tilemaps.TileMapJSON, *tilesets.Set) {
+	b.Helper()
+
+	scope := assets.NewManager(os.DirFS("../assets")).NewScope()
+	tileMap, err := scope.Map("maps/spawn.json", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ts, err := tilesets.NewSetFromMap(scope, "maps/spawn.json", tileMap)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return tileMap, ts
+}
+
+// BenchmarkDrawSpawn compares drawing every tile of the starting map one by
+// one with drawing its cached chunks, on a screen the size of the window.
+// The draws/op metric counts the images drawn to the screen per frame.
+func BenchmarkDrawSpawn(b *testing.B) {
+	tileMap, ts := loadSpawnMap(b)
+	layers := tileMap.FlatLayers()
+	screen := ebiten.NewImage(640, 480)
+
+	b.Run("tiles", func(b *testing.B) {
+		draws := 0
+		for i := 0; i < b.N; i++ {
+			for _, layer := range layers {
+				if layer.Type != tilemaps.TileLayer {
+					continue
+				}
+
+				layer.EachTile(func(x, y int, rawId uint32) {
+					opts := ebiten.DrawImageOptions{}
+					img, _ := PlaceTile(&opts.GeoM, tileMap, ts, x, y, rawId, 0)
+					if img != nil {
+						screen.DrawImage(img, &opts)
+						draws++
+					}
+				})
+			}
+		}
+		b.ReportMetric(float64(draws)/float64(b.N), "draws/op")
+	})
+
+	b.Run("chunks", func(b *testing.B) {
+		renderers := make([]*ChunkedLayer, 0)
+		for i := range layers {
+			if layers[i].Type == tilemaps.TileLayer {
+				renderers = append(renderers, NewChunkedLayer(tileMap, &layers[i], ts))
+			}
+		}
+
+		// render the chunks before timing
+		opts := ebiten.DrawImageOptions{}
+		for _, renderer := range renderers {
+			renderer.Draw(screen, &opts, 0)
+		}
+
+		b.ResetTimer()
+
+		draws := 0
+		for i := 0; i < b.N; i++ {
+			for _, renderer := range renderers {
+				draws += renderer.Draw(screen, &opts, 0)
+			}
+		}
+		b.ReportMetric(float64(draws)/float64(b.N), "draws/op")
+	})
+}
@@ -0,0 +1,56 @@
+package renderers
+
+import (
+	"image"
+	"math"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+	"github.com/ev-the-dev/rpg-tutorial/tilesets"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// PlaceTile sets geoM up to draw the tile with raw gid rawId at tile x, y of
+// tileMap, in map pixels. It returns the image to draw, showing the frame
+// of an animated tile after ms milliseconds, and the area it covers. The
+// image is nil if no tileset has it.
+func PlaceTile(geoM *ebiten.GeoM, tileMap *tilemaps.TileMapJSON, ts *tilesets.Set, x, y int, rawId uint32, ms int) (*ebiten.Image, image.Rectangle) {
+	imgId, flip := tilemaps.DecodeGID(rawId)
+	imgId = ts.Frame(imgId, ms)
+
+	// gids were checked when the colliders were generated
+	img, err := ts.Img(imgId)
+	if err != nil {
+		return nil, image.Rectangle{}
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	fw, fh := flip.Size(w, h)
+
+	// get pixel position of tile
+	pos := tileMap.TilePosition(x, y, image.Rect(0, 0, fw, fh))
+
+	applyFlip(geoM, flip, float64(w), float64(h))
+
+	geoM.Translate(float64(pos.X), float64(pos.Y))
+
+	return img, image.Rect(pos.X, pos.Y, pos.X+fw, pos.Y+fh)
+}
+
+// applyFlip mirrors a w x h tile in place, so that after the transform it
+// still starts at the origin. Tiled applies the diagonal flip first.
+func applyFlip(geoM *ebiten.GeoM, flip tilemaps.Flip, w, h float64) {
+	if flip.Diagonal() {
+		// swap x and y
+		geoM.Rotate(math.Pi / 2)
+		geoM.Scale(-1, 1)
+		w, h = h, w
+	}
+	if flip.Horizontal() {
+		geoM.Scale(-1, 1)
+		geoM.Translate(w, 0)
+	}
+	if flip.Vertical() {
+		geoM.Scale(1, -1)
+		geoM.Translate(0, h)
+	}
+}
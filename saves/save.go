@@ -0,0 +1,128 @@
+package saves
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+)
+
+// file the game is saved to, relative to the working directory
+const DefaultPath = "save.json"
+
+// TileEditJSON is a tile changed while playing, kept so it can be placed
+// again the next time its map is loaded.
+type TileEditJSON struct {
+	Gid   uint32 `json:"gid"` // with flip flags, see tilemaps.DecodeGID
+	Layer string `json:"layer"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+}
+
+// SaveJSON is safe for concurrent use, maps can be loaded in the background
+// while tiles keep being recorded.
+type SaveJSON struct {
+	Maps map[string][]*TileEditJSON `json:"maps"` // tile edits by map path
+
+	mu sync.Mutex
+}
+
+func NewSave() *SaveJSON {
+	return &SaveJSON{
+		Maps: make(map[string][]*TileEditJSON),
+	}
+}
+
+// LoadSave reads the save at path. A missing save is a new game, so it
+// returns an empty one.
+func LoadSave(path string) (*SaveJSON, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewSave(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	save := NewSave()
+	if err := json.Unmarshal(contents, save); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if save.Maps == nil {
+		save.Maps = make(map[string][]*TileEditJSON)
+	}
+
+	return save, nil
+}
+
+func (s *SaveJSON) Write(path string) error {
+	s.mu.Lock()
+	contents, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, contents, 0o644)
+}
+
+// RecordTile stores a tile change made on the map at mapPath, replacing any
+// earlier edit of the same tile.
+func (s *SaveJSON) RecordTile(mapPath string, change tilemaps.TileChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, edit := range s.Maps[mapPath] {
+		if edit.Layer == change.Layer && edit.X == change.X && edit.Y == change.Y {
+			edit.Gid = change.New
+			return
+		}
+	}
+
+	s.Maps[mapPath] = append(s.Maps[mapPath], &TileEditJSON{
+		Gid:   change.New,
+		Layer: change.Layer,
+		X:     change.X,
+		Y:     change.Y,
+	})
+}
+
+// ForMap returns a save holding copies of just the edits of the map at
+// mapPath, e.g. to apply them on another goroutine while tiles keep being
+// recorded.
+func (s *SaveJSON) ForMap(mapPath string) *SaveJSON {
+	save := NewSave()
+	save.Maps[mapPath] = s.edits(mapPath)
+	return save
+}
+
+// edits returns copies of the edits of the map at mapPath.
+func (s *SaveJSON) edits(mapPath string) []*TileEditJSON {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	edits := make([]*TileEditJSON, len(s.Maps[mapPath]))
+	for i, edit := range s.Maps[mapPath] {
+		copied := *edit
+		edits[i] = &copied
+	}
+
+	return edits
+}
+
+// Apply places the tiles edited on the map at mapPath again. It's meant to
+// run before any listeners are registered with tileMap, but works on copies
+// of the edits so listeners recording tiles wouldn't deadlock.
+func (s *SaveJSON) Apply(mapPath string, tileMap *tilemaps.TileMapJSON) error {
+	for _, edit := range s.edits(mapPath) {
+		if err := tileMap.SetTile(edit.Layer, edit.X, edit.Y, edit.Gid); err != nil {
+			return fmt.Errorf("%s: %w", mapPath, err)
+		}
+	}
+
+	return nil
+}
@@ -6,36 +6,75 @@ import (
 	"image/color"
 	"log"
 	"math"
+	"path"
+	"sort"
 
 	"github.com/ev-the-dev/rpg-tutorial/animations"
+	"github.com/ev-the-dev/rpg-tutorial/assets"
 	"github.com/ev-the-dev/rpg-tutorial/cameras"
 	"github.com/ev-the-dev/rpg-tutorial/components"
 	"github.com/ev-the-dev/rpg-tutorial/constants"
 	"github.com/ev-the-dev/rpg-tutorial/entities"
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+	"github.com/ev-the-dev/rpg-tutorial/renderers"
+	"github.com/ev-the-dev/rpg-tutorial/saves"
+	"github.com/ev-the-dev/rpg-tutorial/spawns"
 	"github.com/ev-the-dev/rpg-tutorial/spritesheet"
 	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
-	"github.com/ev-the-dev/rpg-tutorial/tilesets"
+	"github.com/ev-the-dev/rpg-tutorial/worlds"
 	ebiten "github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
+// pixels around the view within which the maps of a world are loaded. Maps
+// are only unloaded beyond twice that, so walking along a map's border
+// doesn't load and unload it over and over.
+const worldStreamMargin = 256
+
 type GameScene struct {
+	assets            *assets.Manager
 	camera            *cameras.Camera
-	colliders         []image.Rectangle
+	colliders         []image.Rectangle // of all levels
 	enemies           []*entities.Enemy
+	levels            []*level // the loaded maps, in the order they were loaded
 	loaded            bool
+	loading           *LoadingScene // loads the maps warps lead to, and shows errors
+	onWarp            bool          // warps only trigger when walked into, not while standing in them
+	placingPlayer     bool          // whether player spawn points move the player, only while arriving on a map
 	player            *entities.Player
 	playerSpriteSheet *spritesheet.SpriteSheet
 	potions           []*entities.Potion
+	registry          *spawns.Registry
+	save              *saves.SaveJSON
+	scope             *assets.Scope       // the scene's own images, kept while the game runs
+	showAssets        bool                // debug overlay listing the loaded assets, toggled with F3
+	streamed          chan *streamedLevel // receives the maps of the world loaded in the background
+	streaming         map[string]bool     // maps of the world loading in the background
+	targetSpawn       string              // player spawn point a warp leads to while its map loads
+	tick              int                 // game ticks played, drives tile animations
 	tileMapImg        *ebiten.Image
-	tileMapJSON       *tilemaps.TileMapJSON
-	tilesets          []tilesets.Tileset
+	world             *worlds.World // nil unless the current map is part of one
+}
+
+// streamedLevel is a map of a world loaded in the background, see
+// streamWorld.
+type streamedLevel struct {
+	err   error
+	lvl   *level
+	path  string
+	world *worlds.World // the world it was loaded for
 }
 
-func NewGameScene() *GameScene {
-	return &GameScene{}
+func NewGameScene(manager *assets.Manager, loading *LoadingScene) *GameScene {
+	return &GameScene{
+		assets:    manager,
+		loading:   loading,
+		scope:     manager.NewScope(),
+		streamed:  make(chan *streamedLevel),
+		streaming: make(map[string]bool),
+	}
 }
 
 /*
@@ -50,17 +89,7 @@ func (g *GameScene) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{120, 180, 255, 255})
 	opts := ebiten.DrawImageOptions{}
 
-	g.drawBackground(screen, &opts)
-
-	g.drawPlayer(screen, g.player.Sprite, &opts)
-
-	for _, enemy := range g.enemies {
-		g.drawSprite(screen, enemy.Sprite, &opts)
-	}
-
-	for _, potion := range g.potions {
-		g.drawSprite(screen, potion.Sprite, &opts)
-	}
+	g.drawLayers(screen, &opts)
 
 	for _, collider := range g.colliders {
 		vector.StrokeRect(
@@ -74,69 +103,56 @@ func (g *GameScene) Draw(screen *ebiten.Image) {
 			true,
 		)
 	}
-}
 
-func (g *GameScene) FirstLoad() {
-	playerImg, _, err := ebitenutil.NewImageFromFile("./assets/images/ninja.png")
-	if err != nil {
-		log.Fatalf("playerImg err: %v", err)
+	if g.showAssets {
+		ebitenutil.DebugPrint(screen, g.assets.Report())
 	}
+}
 
-	playerSpriteSheet := spritesheet.NewSpriteSheet(4, 7, constants.Tilesize)
+func (g *GameScene) FirstLoad(progress *Progress) (err error) {
+	// a failed load starts over from scratch when retried
+	defer func() {
+		if err != nil {
+			g.scope.Release()
+		}
+	}()
+
+	progress.Expect(6)
 
-	potionImg, _, err := ebitenutil.NewImageFromFile("./assets/images/heart_potion.png")
+	progress.Step("images/ninja.png")
+	playerImg, err := g.scope.Image("images/ninja.png")
 	if err != nil {
-		log.Fatalf("potionImg err: %v", err)
+		return fmt.Errorf("playerImg err: %w", err)
 	}
 
-	skeletonImg, _, err := ebitenutil.NewImageFromFile("./assets/images/skeleton.png")
+	playerSpriteSheet := spritesheet.NewSpriteSheet(4, 7, constants.Tilesize)
+
+	progress.Step("images/heart_potion.png")
+	potionImg, err := g.scope.Image("images/heart_potion.png")
 	if err != nil {
-		log.Fatalf("skeletonImg err: %v", err)
+		return fmt.Errorf("potionImg err: %w", err)
 	}
 
-	tileMapImg, _, err := ebitenutil.NewImageFromFile("./assets/images/TilesetFloor.png")
+	progress.Step("images/skeleton.png")
+	skeletonImg, err := g.scope.Image("images/skeleton.png")
 	if err != nil {
-		log.Fatalf("tileMapImg err: %v", err)
+		return fmt.Errorf("skeletonImg err: %w", err)
 	}
 
-	tileMapJson, err := tilemaps.NewTileMapJSON("./assets/maps/spawn.json")
+	progress.Step("images/TilesetFloor.png")
+	tileMapImg, err := g.scope.Image("images/TilesetFloor.png")
 	if err != nil {
-		log.Fatalf("tileMapJson err: %v", err)
+		return fmt.Errorf("tileMapImg err: %w", err)
 	}
 
-	tilesets, err := tileMapJson.GenTilesets()
+	progress.Step(saves.DefaultPath)
+	save, err := saves.LoadSave(saves.DefaultPath)
 	if err != nil {
-		log.Fatalf("tilesets err: %v", err)
-		log.Fatal(err)
+		return fmt.Errorf("save err: %w", err)
 	}
 
 	g.camera = cameras.NewCamera(0.0, 0.0)
 
-	g.colliders = []image.Rectangle{
-		image.Rect(100, 100, 116, 116),
-	}
-
-	g.enemies = []*entities.Enemy{
-		{
-			CombatComp:    components.NewEnemyCombat(30, 1, 3),
-			FollowsPlayer: true,
-			Sprite: &entities.Sprite{
-				Img: skeletonImg,
-				X:   100,
-				Y:   100,
-			},
-		},
-		{
-			CombatComp:    components.NewEnemyCombat(30, 1, 3),
-			FollowsPlayer: false,
-			Sprite: &entities.Sprite{
-				Img: skeletonImg,
-				X:   150,
-				Y:   150,
-			},
-		},
-	}
-
 	g.player = &entities.Player{
 		Animations: map[entities.PlayerState]*animations.Animation{
 			entities.Up:    animations.NewAnimation(5, 13, 4, 20.0),
@@ -155,21 +171,228 @@ func (g *GameScene) FirstLoad() {
 
 	g.playerSpriteSheet = playerSpriteSheet
 
-	g.potions = []*entities.Potion{
-		{
-			Sprite: &entities.Sprite{
-				Img: potionImg,
-				X:   210,
-				Y:   100,
-			},
-			AmtHeal: 1,
-		},
+	registry := spawns.NewRegistry()
+	registry.Register(spawns.EnemySkeleton, g.spawnEnemy(skeletonImg))
+	registry.Register(spawns.PlayerSpawn, g.spawnPlayer)
+	registry.Register(spawns.PotionHeart, g.spawnPotion(potionImg))
+
+	g.registry = registry
+	g.save = save
+
+	progress.Step("maps/spawn.json")
+	if err := g.loadMap("maps/spawn.json", ""); err != nil {
+		return fmt.Errorf("map err: %w", err)
 	}
 
 	g.tileMapImg = tileMapImg
-	g.tileMapJSON = tileMapJson
-	g.tilesets = tilesets
 	g.loaded = true
+
+	return nil
+}
+
+// loadMap replaces the loaded maps with the map at mapPath and spawns its
+// entities. The player carries over and is placed on the player spawn point
+// named spawn, or any of them if spawn is empty. If the map is part of a
+// world, its neighbours are loaded as they come into view.
+func (g *GameScene) loadMap(mapPath string, spawn string) error {
+	mapPath = path.Clean(mapPath)
+
+	world, err := worlds.FindWorld(g.assets.FS(), mapPath)
+	if err != nil {
+		return err
+	}
+
+	origin := image.Point{}
+	if world != nil {
+		worldMap := world.Map(mapPath)
+		origin = image.Pt(worldMap.X, worldMap.Y)
+	}
+
+	lvl, err := newLevel(g.assets, mapPath, origin, g.save)
+	if err != nil {
+		return err
+	}
+
+	// the map's entities are spawned before the old ones are dropped, which
+	// stay as they are if that fails
+	enemies, potions := g.enemies, g.potions
+	playerX, playerY := g.player.X, g.player.Y
+	g.enemies = make([]*entities.Enemy, 0)
+	g.potions = make([]*entities.Potion, 0)
+
+	g.placingPlayer = true
+	g.targetSpawn = spawn
+	err = g.spawnEntities(lvl)
+	if err == nil && g.placingPlayer && spawn != "" {
+		err = fmt.Errorf("%s: no player spawn named %q", mapPath, spawn)
+	}
+	g.placingPlayer = false
+
+	if err != nil {
+		g.enemies, g.potions = enemies, potions
+		g.player.X, g.player.Y = playerX, playerY
+		lvl.release()
+		return err
+	}
+
+	// the new map is loaded before the old ones are released, so assets
+	// they share stay loaded
+	for _, old := range g.levels {
+		old.release()
+	}
+
+	g.levels = make([]*level, 0)
+	g.world = world
+	g.addLevel(lvl)
+
+	// arriving on a warp doesn't warp back right away
+	g.onWarp = true
+
+	return nil
+}
+
+// spawnEntities spawns the entities of a loaded map.
+func (g *GameScene) spawnEntities(lvl *level) error {
+	for _, spawnPoint := range lvl.tileMap.SpawnPoints() {
+		spawnPoint.X += float64(lvl.origin.X)
+		spawnPoint.Y += float64(lvl.origin.Y)
+		if err := g.registry.Spawn(spawnPoint); err != nil {
+			return fmt.Errorf("%s: %w", lvl.path, err)
+		}
+	}
+
+	return nil
+}
+
+// addLevel adds a loaded map whose entities were spawned to the scene.
+func (g *GameScene) addLevel(lvl *level) {
+	lvl.unregister = lvl.tileMap.OnTileChange(func(change tilemaps.TileChange) {
+		g.onTileChange(lvl, change)
+	})
+
+	g.levels = append(g.levels, lvl)
+	g.updateColliders()
+}
+
+// streamWorld loads the maps of the world that come close to the view in
+// the background and unloads the ones far away from it, along with the
+// entities on them.
+func (g *GameScene) streamWorld(screenWidth, screenHeight int) error {
+	if err := g.addStreamed(); err != nil {
+		return err
+	}
+
+	if g.world == nil {
+		return nil
+	}
+
+	view := image.Rect(0, 0, screenWidth, screenHeight).Sub(image.Pt(int(g.camera.X), int(g.camera.Y)))
+	keepArea := view.Inset(-2 * worldStreamMargin)
+
+	levels := make([]*level, 0, len(g.levels))
+	for _, lvl := range g.levels {
+		if lvl.bounds().Overlaps(keepArea) {
+			levels = append(levels, lvl)
+			continue
+		}
+
+		g.removeEntitiesIn(lvl.bounds())
+		lvl.release()
+	}
+	if len(levels) != len(g.levels) {
+		g.levels = levels
+		g.updateColliders()
+	}
+
+	for _, worldMap := range g.world.Near(view.Inset(-worldStreamMargin)) {
+		mapPath := g.world.Path(worldMap)
+		if g.level(mapPath) != nil || g.streaming[mapPath] {
+			continue
+		}
+
+		// the save keeps recording tiles while the map loads
+		origin, save, world := image.Pt(worldMap.X, worldMap.Y), g.save.ForMap(mapPath), g.world
+		g.streaming[mapPath] = true
+		go func() {
+			lvl, err := newLevel(g.assets, mapPath, origin, save)
+			g.streamed <- &streamedLevel{err: err, lvl: lvl, path: mapPath, world: world}
+		}()
+	}
+
+	return nil
+}
+
+// addStreamed adds the maps of the world loaded in the background since the
+// last update to the scene, unless the player left the world meanwhile.
+func (g *GameScene) addStreamed() error {
+	for {
+		select {
+		case streamed := <-g.streamed:
+			delete(g.streaming, streamed.path)
+
+			if streamed.world != g.world {
+				if streamed.lvl != nil {
+					streamed.lvl.release()
+				}
+				continue
+			}
+			if streamed.err != nil {
+				return streamed.err
+			}
+
+			lvl := streamed.lvl
+			if err := g.spawnEntities(lvl); err != nil {
+				g.removeEntitiesIn(lvl.bounds())
+				lvl.release()
+				return err
+			}
+			g.addLevel(lvl)
+		default:
+			return nil
+		}
+	}
+}
+
+// level returns the loaded map at mapPath, or nil if it isn't loaded.
+func (g *GameScene) level(mapPath string) *level {
+	for _, lvl := range g.levels {
+		if lvl.path == mapPath {
+			return lvl
+		}
+	}
+
+	return nil
+}
+
+// removeEntitiesIn drops the enemies and potions within area, which is
+// where an unloaded map used to be. Entities belong to the map they're on,
+// not the one they were spawned by.
+func (g *GameScene) removeEntitiesIn(area image.Rectangle) {
+	enemies := make([]*entities.Enemy, 0, len(g.enemies))
+	for _, enemy := range g.enemies {
+		if !image.Pt(int(enemy.X), int(enemy.Y)).In(area) {
+			enemies = append(enemies, enemy)
+		}
+	}
+	g.enemies = enemies
+
+	potions := make([]*entities.Potion, 0, len(g.potions))
+	for _, potion := range g.potions {
+		if !image.Pt(int(potion.X), int(potion.Y)).In(area) {
+			potions = append(potions, potion)
+		}
+	}
+	g.potions = potions
+}
+
+// updateColliders gathers the colliders of all loaded maps.
+func (g *GameScene) updateColliders() {
+	colliders := make([]image.Rectangle, 0)
+	for _, lvl := range g.levels {
+		colliders = append(colliders, lvl.colliders...)
+	}
+
+	g.colliders = colliders
 }
 
 func (g *GameScene) IsLoaded() bool {
@@ -180,6 +403,9 @@ func (g *GameScene) OnEnter() {
 }
 
 func (g *GameScene) OnExit() {
+	if err := g.save.Write(saves.DefaultPath); err != nil {
+		log.Printf("save err: %v", err)
+	}
 }
 
 func (g *GameScene) Update() SceneId {
@@ -189,6 +415,11 @@ func (g *GameScene) Update() SceneId {
 	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
 		return PauseSceneId
 	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.showAssets = !g.showAssets
+	}
+
+	g.tick++
 
 	g.player.Dx = 0.0
 	g.player.Dy = 0.0
@@ -213,11 +444,47 @@ func (g *GameScene) Update() SceneId {
 	g.player.Y += g.player.Dy
 	checkCollisionVertical(g.player.Sprite, g.colliders)
 
+	lvl, warp := g.warpAt(g.player.Sprite)
+	if warp != nil && !g.onWarp {
+		// if the map fails to load, the player is back on the warp, which
+		// only triggers again once walked into
+		g.onWarp = true
+
+		mapPath, spawn := tilemaps.ResolvePath(path.Dir(lvl.path), warp.Map), warp.Spawn
+		g.loading.Run(func(progress *Progress) error {
+			progress.Expect(1)
+			progress.Step(mapPath)
+			if err := g.loadMap(mapPath, spawn); err != nil {
+				return fmt.Errorf("warp err: %w", err)
+			}
+			return nil
+		}, GameSceneId, GameSceneId)
+
+		return LoadingSceneId
+	}
+	g.onWarp = warp != nil
+
 	activeAnim := g.player.ActiveAnimation(int(g.player.Dx), int(g.player.Dy))
 	if activeAnim != nil {
 		activeAnim.Update()
 	}
 
+	// the camera centers on the player, without showing past the edges of
+	// the loaded maps
+	screenWidth, screenHeight := ebiten.WindowSize()
+	g.camera.FollowTarget(
+		g.player.X+constants.Tilesize/2,
+		g.player.Y+constants.Tilesize/2,
+		float64(screenWidth),
+		float64(screenHeight),
+	)
+	g.camera.Constrain(g.bounds(), float64(screenWidth), float64(screenHeight))
+
+	if err := g.streamWorld(screenWidth, screenHeight); err != nil {
+		g.loading.Fail(fmt.Errorf("world err: %w", err), GameSceneId)
+		return LoadingSceneId
+	}
+
 	for _, enemy := range g.enemies {
 		enemy.Dx = 0.0
 		enemy.Dy = 0.0
@@ -245,9 +512,11 @@ func (g *GameScene) Update() SceneId {
 
 	clicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0)
 	cX, cY := ebiten.CursorPosition()
-	// ensures cursor coordinate follows camera movement/accounts for camera offset
-	cX += int(g.camera.X)
-	cY += int(g.camera.Y)
+	// ensures cursor coordinate follows camera movement/accounts for camera offset.
+	// The camera holds how far the world is moved on screen, so it's
+	// subtracted to get from screen to world pixels.
+	cX -= int(g.camera.X)
+	cY -= int(g.camera.Y)
 
 	g.player.CombatComp.Update()
 	playerRect := image.Rect(
@@ -305,62 +574,148 @@ func (g *GameScene) Update() SceneId {
 	return GameSceneId
 }
 
-func (g *GameScene) drawBackground(screen *ebiten.Image, opts *ebiten.DrawImageOptions) {
-	// loop over each layer
-	for layerIndex, layer := range g.tileMapJSON.Layers {
-		// loop over tiles in layer
-		for imgIdx, imgId := range layer.Data {
+// bounds returns the area the camera may show, in world pixels: the whole
+// world if the current map is part of one, otherwise the map.
+func (g *GameScene) bounds() image.Rectangle {
+	if g.world != nil {
+		return g.world.Bounds()
+	}
 
-			if imgId == 0 {
-				continue
-			}
+	return g.levels[0].bounds()
+}
 
-			// get tile position of tile
-			x := imgIdx % layer.Width
-			y := imgIdx / layer.Width
-			// convert tile position to pixel position
-			x *= constants.Tilesize
-			y *= constants.Tilesize
+// onTileChange keeps everything derived from a map's tiles up to date when
+// one of them is replaced at runtime, and records the change in the save.
+func (g *GameScene) onTileChange(lvl *level, change tilemaps.TileChange) {
+	g.save.RecordTile(lvl.path, change)
 
-			img := g.tilesets[layerIndex].Img(imgId)
+	for i, layer := range lvl.tileMap.FlatLayers() {
+		if layer.Name == change.Layer && lvl.layerRenderers[i] != nil {
+			lvl.layerRenderers[i].Invalidate(change.X, change.Y)
+		}
+	}
 
-			opts.GeoM.Translate(float64(x), float64(y))
+	if change.Layer == tilemaps.DepthLayerName {
+		size := placedTileSize(change.New, lvl.tilesets)
+		lvl.depthTileSize = image.Pt(max(lvl.depthTileSize.X, size.X), max(lvl.depthTileSize.Y, size.Y))
+	}
 
-			opts.GeoM.Translate(0.0, -(float64(img.Bounds().Dy()) + constants.Tilesize))
+	if err := lvl.replaceTileColliders(change); err != nil {
+		log.Printf("colliders err: %v", err)
+		return
+	}
 
-			opts.GeoM.Translate(g.camera.X, g.camera.Y)
+	g.updateColliders()
+}
 
-			screen.DrawImage(img, opts)
+/*
+* NOTE: Sprites are drawn in the depth sorted pass together
+* with the tiles of each map's depth layer, between the layers
+* below and above it. Maps without one have all their layers
+* drawn below the sprites.
+ */
+func (g *GameScene) drawLayers(screen *ebiten.Image, opts *ebiten.DrawImageOptions) {
+	for _, lvl := range g.levels {
+		g.drawLevelLayers(screen, lvl, false, opts)
+	}
 
-			opts.GeoM.Reset()
+	g.drawDepthSorted(screen)
+
+	for _, lvl := range g.levels {
+		g.drawLevelLayers(screen, lvl, true, opts)
+	}
+}
+
+// drawLevelLayers draws the layers of a map that are either above or below
+// its depth layer.
+func (g *GameScene) drawLevelLayers(screen *ebiten.Image, lvl *level, above bool, opts *ebiten.DrawImageOptions) {
+	// loop over each layer, groups are flattened into the layers they hold
+	for i, layer := range lvl.tileMap.FlatLayers() {
+		if i == lvl.depthLayer || !layer.Visible {
+			continue
+		}
+		if (lvl.depthLayer >= 0 && i > lvl.depthLayer) != above {
+			continue
+		}
 
-			// // get the position on the TileSet image where the tile ID is
-			// srcX := (imgId - 1) % 22 // 22 hardcoded because tileset file shows last index on row as id 21 (0th based)
-			// srcY := (imgId - 1) / 22
-			// // convert the src tile position to src pixel position
-			// srcX *= constants.Tilesize
-			// srcY *= constants.Tilesize
-
-			// // draw tile at appropriate x,y position
-			// opts.GeoM.Translate(float64(x), float64(y))
-
-			// opts.GeoM.Translate(g.camera.X, g.camera.Y)
-			// // draw the tile
-			// screen.DrawImage(
-			// 	// cropping out the tile we want from the spritesheet
-			// 	g.tileMapImg.SubImage(image.Rect(srcX, srcY, srcX+constants.Tilesize, srcY+constants.Tilesize)).(*ebiten.Image),
-			// 	opts,
-			// )
-			// // reset the opts for the next tile
-			// opts.GeoM.Reset()
+		switch layer.Type {
+		case tilemaps.ImageLayer:
+			g.drawImageLayer(screen, lvl, &layer, opts)
+		case tilemaps.TileLayer:
+			g.drawTileLayer(screen, lvl, lvl.layerRenderers[i], &layer, opts)
 		}
 	}
 }
 
-// Temp
-func (g *GameScene) drawPlayer(screen *ebiten.Image, sprite *entities.Sprite, opts *ebiten.DrawImageOptions) {
-	opts.GeoM.Translate(sprite.X, sprite.Y)
-	opts.GeoM.Translate(g.camera.X, g.camera.Y)
+// drawTileLayer draws a tile layer through its renderer, which only draws
+// the cached chunks of it that are on screen.
+func (g *GameScene) drawTileLayer(screen *ebiten.Image, lvl *level, renderer *renderers.ChunkedLayer, layer *tilemaps.TileMapLayerJSON, opts *ebiten.DrawImageOptions) {
+	layerX, layerY := g.layerTranslation(lvl, layer, screen)
+
+	opts.GeoM.Translate(layerX, layerY)
+
+	opts.ColorScale.ScaleWithColor(layer.TintColor)
+	opts.ColorScale.ScaleAlpha(float32(layer.Opacity))
+
+	renderer.Draw(screen, opts, g.tick*1000/ebiten.TPS())
+
+	opts.GeoM.Reset()
+	opts.ColorScale.Reset()
+}
+
+// tileOpts sets up opts to draw the tile with raw gid rawId placed at tile
+// x, y of a map's layer whose origin is at layerX, layerY on screen. It
+// returns the image to draw, or nil if there's none, and the screen y of the
+// tile's foot.
+func (g *GameScene) tileOpts(opts *ebiten.DrawImageOptions, lvl *level, layer *tilemaps.TileMapLayerJSON, x, y int, rawId uint32, layerX, layerY float64) (*ebiten.Image, float64) {
+	img, bounds := renderers.PlaceTile(&opts.GeoM, lvl.tileMap, lvl.tilesets, x, y, rawId, g.tick*1000/ebiten.TPS())
+	if img == nil {
+		return nil, 0
+	}
+
+	opts.GeoM.Translate(layerX, layerY)
+
+	opts.ColorScale.ScaleWithColor(layer.TintColor)
+	opts.ColorScale.ScaleAlpha(float32(layer.Opacity))
+
+	imgId, _ := tilemaps.DecodeGID(rawId)
+	footY := layerY + float64(bounds.Max.Y-lvl.tilesets.Anchor(imgId))
+
+	return img, footY
+}
+
+// depthItem is an image drawn in the depth sorted pass. Items whose foot is
+// further down the screen are drawn over the ones above them.
+type depthItem struct {
+	footY float64
+	img   *ebiten.Image
+	opts  ebiten.DrawImageOptions
+}
+
+// drawDepthSorted draws the tiles of the maps' depth layers and the player,
+// enemies and potions in the order of their feet.
+func (g *GameScene) drawDepthSorted(screen *ebiten.Image) {
+	items := make([]depthItem, 0, len(g.enemies)+len(g.potions)+1)
+
+	for _, lvl := range g.levels {
+		if lvl.depthLayer < 0 {
+			continue
+		}
+
+		layer := lvl.tileMap.FlatLayers()[lvl.depthLayer]
+		if !layer.Visible {
+			continue
+		}
+
+		layerX, layerY := g.layerTranslation(lvl, &layer, screen)
+		layer.EachTileIn(g.depthArea(lvl, screen, layerX, layerY), func(x, y int, rawId uint32) {
+			item := depthItem{}
+			item.img, item.footY = g.tileOpts(&item.opts, lvl, &layer, x, y, rawId, layerX, layerY)
+			if item.img != nil {
+				items = append(items, item)
+			}
+		})
+	}
 
 	playerFrame := 0
 	activeAnim := g.player.ActiveAnimation(int(g.player.Dx), int(g.player.Dy))
@@ -368,24 +723,199 @@ func (g *GameScene) drawPlayer(screen *ebiten.Image, sprite *entities.Sprite, op
 		playerFrame = activeAnim.Frame()
 	}
 
-	screen.DrawImage(sprite.Img.SubImage(
-		g.playerSpriteSheet.Rect(playerFrame),
-	).(*ebiten.Image),
-		opts)
+	items = append(items, g.spriteItem(g.player.Sprite, g.playerSpriteSheet.Rect(playerFrame)))
 
-	opts.GeoM.Reset()
+	for _, enemy := range g.enemies {
+		items = append(items, g.spriteItem(enemy.Sprite, image.Rect(0, 0, constants.Tilesize, constants.Tilesize)))
+	}
+
+	for _, potion := range g.potions {
+		items = append(items, g.spriteItem(potion.Sprite, image.Rect(0, 0, constants.Tilesize, constants.Tilesize)))
+	}
+
+	// stable, so tiles and sprites sharing a foot keep the order above
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].footY < items[j].footY
+	})
+
+	for i := range items {
+		screen.DrawImage(items[i].img, &items[i].opts)
+	}
 }
 
-func (g *GameScene) drawSprite(screen *ebiten.Image, sprite *entities.Sprite, opts *ebiten.DrawImageOptions) {
-	opts.GeoM.Translate(sprite.X, sprite.Y)
-	opts.GeoM.Translate(g.camera.X, g.camera.Y)
+// depthArea returns the tiles of a level's depth layer that can show on
+// screen when the layer's origin is at layerX, layerY. Tile images are
+// anchored to the bottom left of their cell and can be larger than it, so the
+// view is grown by the largest of them to the left and below.
+func (g *GameScene) depthArea(lvl *level, screen *ebiten.Image, layerX, layerY float64) image.Rectangle {
+	view := image.Rect(
+		int(math.Floor(-layerX))-lvl.depthTileSize.X,
+		int(math.Floor(-layerY)),
+		int(math.Ceil(-layerX))+screen.Bounds().Dx(),
+		int(math.Ceil(-layerY))+screen.Bounds().Dy()+lvl.depthTileSize.Y,
+	)
 
-	screen.DrawImage(sprite.Img.SubImage(
-		image.Rect(0, 0, constants.Tilesize, constants.Tilesize),
-	).(*ebiten.Image),
-		opts)
+	return lvl.tileMap.TilesIn(view)
+}
 
-	opts.GeoM.Reset()
+// spriteItem places the frame of a sprite's image in the depth sorted pass.
+// Sprites stand on the bottom edge of their tile.
+func (g *GameScene) spriteItem(sprite *entities.Sprite, frame image.Rectangle) depthItem {
+	item := depthItem{
+		footY: sprite.Y + g.camera.Y + constants.Tilesize,
+		img:   sprite.Img.SubImage(frame).(*ebiten.Image),
+	}
+
+	item.opts.GeoM.Translate(sprite.X, sprite.Y)
+	item.opts.GeoM.Translate(g.camera.X, g.camera.Y)
+
+	return item
+}
+
+// drawImageLayer draws the image of an image layer, repeating it across the
+// screen along the axes the layer repeats on.
+func (g *GameScene) drawImageLayer(screen *ebiten.Image, lvl *level, layer *tilemaps.TileMapLayerJSON, opts *ebiten.DrawImageOptions) {
+	img := lvl.layerImgs[layer.Image]
+	if img == nil {
+		return
+	}
+
+	layerX, layerY := g.layerTranslation(lvl, layer, screen)
+	xs := repeatPositions(layerX, float64(img.Bounds().Dx()), float64(screen.Bounds().Dx()), layer.RepeatX)
+	ys := repeatPositions(layerY, float64(img.Bounds().Dy()), float64(screen.Bounds().Dy()), layer.RepeatY)
+
+	for _, y := range ys {
+		for _, x := range xs {
+			opts.GeoM.Translate(x, y)
+
+			opts.ColorScale.ScaleWithColor(layer.TintColor)
+			opts.ColorScale.ScaleAlpha(float32(layer.Opacity))
+
+			screen.DrawImage(img, opts)
+
+			opts.GeoM.Reset()
+			opts.ColorScale.Reset()
+		}
+	}
+}
+
+// repeatPositions returns the screen positions along one axis to draw an
+// image of the given size at. Unless it repeats, that's just where it's
+// placed, otherwise enough copies in line with it to cover the screen.
+func repeatPositions(pos, size, screenSize float64, repeat bool) []float64 {
+	if !repeat || size <= 0 {
+		return []float64{pos}
+	}
+
+	// start with the copy overlapping the screen's edge
+	pos = math.Mod(pos, size)
+	if pos > 0 {
+		pos -= size
+	}
+
+	positions := make([]float64, 0, int(screenSize/size)+2)
+	for ; pos < screenSize; pos += size {
+		positions = append(positions, pos)
+	}
+
+	return positions
+}
+
+// layerTranslation returns where on screen the origin of a map's layer ends
+// up. Like in Tiled, a parallax factor below 1 makes the layer lag behind the
+// camera, measured from the map's parallax origin to the view center.
+func (g *GameScene) layerTranslation(lvl *level, layer *tilemaps.TileMapLayerJSON, screen *ebiten.Image) (float64, float64) {
+	viewCenterX := -g.camera.X + float64(screen.Bounds().Dx())/2
+	viewCenterY := -g.camera.Y + float64(screen.Bounds().Dy())/2
+
+	originX := float64(lvl.origin.X)
+	originY := float64(lvl.origin.Y)
+
+	x := originX + layer.OffsetX + g.camera.X + (1-layer.ParallaxX)*(viewCenterX-originX-lvl.tileMap.ParallaxOriginX)
+	y := originY + layer.OffsetY + g.camera.Y + (1-layer.ParallaxY)*(viewCenterY-originY-lvl.tileMap.ParallaxOriginY)
+
+	return x, y
+}
+
+/*
+* NOTE: Spawn points place the top left corner of an entity's
+* sprite. Their custom properties override the defaults below.
+ */
+func (g *GameScene) spawnEnemy(img *ebiten.Image) spawns.Factory {
+	return func(obj *objects.ObjectJSON) error {
+		props := obj.Properties
+		health := props.Int("health", 3)
+		if health <= 0 {
+			return fmt.Errorf("enemy health must be positive, got %d", health)
+		}
+
+		g.enemies = append(g.enemies, &entities.Enemy{
+			CombatComp: components.NewEnemyCombat(
+				props.Int("cooldown", 30),
+				props.Int("attackPower", 1),
+				health,
+			),
+			FollowsPlayer: props.Bool("followsPlayer", false),
+			Sprite: &entities.Sprite{
+				Img: img,
+				X:   obj.X,
+				Y:   obj.Y,
+			},
+		})
+
+		return nil
+	}
+}
+
+func (g *GameScene) spawnPlayer(obj *objects.ObjectJSON) error {
+	// only the map the player arrives on places them, and after a warp
+	// only on the spawn point it names
+	if !g.placingPlayer || g.targetSpawn != "" && obj.Name != g.targetSpawn {
+		return nil
+	}
+
+	g.player.X = obj.X
+	g.player.Y = obj.Y
+	if g.targetSpawn != "" {
+		g.placingPlayer = false
+	}
+
+	return nil
+}
+
+func (g *GameScene) spawnPotion(img *ebiten.Image) spawns.Factory {
+	return func(obj *objects.ObjectJSON) error {
+		amtHeal := obj.Properties.Int("amtHeal", 1)
+		if amtHeal < 0 {
+			return fmt.Errorf("potion amtHeal must not be negative, got %d", amtHeal)
+		}
+
+		g.potions = append(g.potions, &entities.Potion{
+			Sprite: &entities.Sprite{
+				Img: img,
+				X:   obj.X,
+				Y:   obj.Y,
+			},
+			AmtHeal: uint(amtHeal),
+		})
+
+		return nil
+	}
+}
+
+// warpAt returns the warp whose area a sprite overlaps and the map it's
+// on, or nil if there's none.
+func (g *GameScene) warpAt(sprite *entities.Sprite) (*level, *tilemaps.Warp) {
+	rect := image.Rect(int(sprite.X), int(sprite.Y), int(sprite.X+constants.Tilesize), int(sprite.Y+constants.Tilesize))
+	for _, lvl := range g.levels {
+		for _, warp := range lvl.warps {
+			if warp.Area.Overlaps(rect) {
+				return lvl, warp
+			}
+		}
+	}
+
+	return nil, nil
 }
 
 func checkCollisionHorizontal(sprite *entities.Sprite, colliders []image.Rectangle) {
@@ -0,0 +1,230 @@
+package scenes
+
+import (
+	"fmt"
+	"image"
+	"path"
+	"slices"
+
+	"github.com/ev-the-dev/rpg-tutorial/assets"
+	"github.com/ev-the-dev/rpg-tutorial/autotiles"
+	"github.com/ev-the-dev/rpg-tutorial/renderers"
+	"github.com/ev-the-dev/rpg-tutorial/saves"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+	"github.com/ev-the-dev/rpg-tutorial/tilesets"
+	ebiten "github.com/hajimehoshi/ebiten/v2"
+)
+
+// level is a map loaded into the game scene together with everything
+// derived from it. Maps of a world sit at their place in it, other maps at
+// the origin, and positions outside of tilemaps are all in those world
+// pixels.
+type level struct {
+	colliders      []image.Rectangle
+	depthLayer     int                       // index into the flattened layers, -1 if the map has none
+	depthTileSize  image.Point               // largest tile image on the depth layer, see GameScene.depthArea
+	layerImgs      map[string]*ebiten.Image  // image layer images by their path in the map
+	layerRenderers []*renderers.ChunkedLayer // by index into the flattened layers, nil if not a tile layer
+	origin         image.Point               // world position of the map's top left corner
+	path           string
+	scope          *assets.Scope // holds the map, its tilesets and images until release
+	tileMap        *tilemaps.TileMapJSON
+	tilesets       *tilesets.Set
+	unregister     func() // stops telling the game scene about the map's tile changes, nil until it does
+	warps          []*tilemaps.Warp
+}
+
+// newLevel loads the map at mapPath and everything it needs through
+// manager. The level holds on to them until it's released.
+func newLevel(manager *assets.Manager, mapPath string, origin image.Point, save *saves.SaveJSON) (*level, error) {
+	scope := manager.NewScope()
+	lvl, err := loadLevel(scope, mapPath, origin, save)
+	if err != nil {
+		scope.Release()
+		return nil, err
+	}
+
+	return lvl, nil
+}
+
+func loadLevel(scope *assets.Scope, mapPath string, origin image.Point, save *saves.SaveJSON) (*level, error) {
+	// shared with any other level of the same map still loaded, which got
+	// it prepared already
+	tileMapJson, err := scope.Map(mapPath, func(tileMapJson *tilemaps.TileMapJSON) error {
+		return prepareMap(scope, mapPath, tileMapJson, save)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tilesets, err := tilesets.NewSetFromMap(scope, mapPath, tileMapJson)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", mapPath, err)
+	}
+
+	warps, err := tileMapJson.Warps()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", mapPath, err)
+	}
+
+	for _, warp := range warps {
+		warp.Area = warp.Area.Add(origin)
+	}
+
+	flatLayers := tileMapJson.FlatLayers()
+
+	depthLayer := -1
+	depthTileSize := image.Point{}
+	layerRenderers := make([]*renderers.ChunkedLayer, len(flatLayers))
+	for i := range flatLayers {
+		if flatLayers[i].Type != tilemaps.TileLayer {
+			continue
+		}
+
+		if flatLayers[i].Name == tilemaps.DepthLayerName && depthLayer < 0 {
+			depthLayer = i
+			depthTileSize = maxTileSize(&flatLayers[i], tilesets)
+			continue
+		}
+
+		layerRenderers[i] = renderers.NewChunkedLayer(tileMapJson, &flatLayers[i], tilesets)
+	}
+
+	layerImgs := make(map[string]*ebiten.Image)
+	for _, layer := range flatLayers {
+		if layer.Type != tilemaps.ImageLayer || layer.Image == "" {
+			continue
+		}
+		if _, exists := layerImgs[layer.Image]; exists {
+			continue
+		}
+
+		layerImg, err := scope.Image(tilemaps.ResolvePath(path.Dir(mapPath), layer.Image))
+		if err != nil {
+			return nil, fmt.Errorf("%s: image layer %q: %w", mapPath, layer.Name, err)
+		}
+
+		layerImgs[layer.Image] = layerImg
+	}
+
+	lvl := level{
+		depthLayer:     depthLayer,
+		depthTileSize:  depthTileSize,
+		layerImgs:      layerImgs,
+		layerRenderers: layerRenderers,
+		origin:         origin,
+		path:           mapPath,
+		scope:          scope,
+		tileMap:        tileMapJson,
+		tilesets:       tilesets,
+		warps:          warps,
+	}
+
+	if err := lvl.updateColliders(); err != nil {
+		return nil, err
+	}
+
+	return &lvl, nil
+}
+
+// prepareMap finishes a map the asset manager just loaded, once for every
+// level sharing it.
+func prepareMap(scope *assets.Scope, mapPath string, tileMapJson *tilemaps.TileMapJSON, save *saves.SaveJSON) error {
+	tilesets, err := tilesets.NewSetFromMap(scope, mapPath, tileMapJson)
+	if err != nil {
+		return fmt.Errorf("%s: %w", mapPath, err)
+	}
+
+	// terrain transitions, before the tiles the player changed go on top
+	if err := autotiles.ResolveLayers(tileMapJson, tilesets); err != nil {
+		return fmt.Errorf("%s: %w", mapPath, err)
+	}
+
+	// tiles changed in earlier sessions
+	return save.Apply(mapPath, tileMapJson)
+}
+
+// bounds returns the area covered by the level's map in world pixels.
+func (l *level) bounds() image.Rectangle {
+	return l.tileMap.PixelBounds().Add(l.origin)
+}
+
+// release frees the chunks the level rendered and lets go of its assets,
+// once it's unloaded.
+func (l *level) release() {
+	if l.unregister != nil {
+		l.unregister()
+	}
+
+	for _, renderer := range l.layerRenderers {
+		if renderer != nil {
+			renderer.Deallocate()
+		}
+	}
+
+	l.scope.Release()
+}
+
+// updateColliders generates the colliders of the level's whole map.
+func (l *level) updateColliders() error {
+	colliders, err := l.tileMap.Colliders(l.tilesets)
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.path, err)
+	}
+
+	for i := range colliders {
+		colliders[i] = colliders[i].Add(l.origin)
+	}
+
+	l.colliders = colliders
+	return nil
+}
+
+// replaceTileColliders swaps the colliders of the tile that change replaced
+// for those of the new one, leaving the rest of the map's as they are.
+func (l *level) replaceTileColliders(change tilemaps.TileChange) error {
+	oldColliders, err := l.tileMap.TileColliders(l.tilesets, change.Layer, change.X, change.Y, change.Old)
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.path, err)
+	}
+
+	newColliders, err := l.tileMap.TileColliders(l.tilesets, change.Layer, change.X, change.Y, change.New)
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.path, err)
+	}
+
+	for _, collider := range oldColliders {
+		if i := slices.Index(l.colliders, collider.Add(l.origin)); i >= 0 {
+			l.colliders = slices.Delete(l.colliders, i, i+1)
+		}
+	}
+	for _, collider := range newColliders {
+		l.colliders = append(l.colliders, collider.Add(l.origin))
+	}
+
+	return nil
+}
+
+// maxTileSize returns the size of the largest tile image placed on layer,
+// as it's placed.
+func maxTileSize(layer *tilemaps.TileMapLayerJSON, ts *tilesets.Set) image.Point {
+	size := image.Point{}
+	layer.EachTile(func(x, y int, rawId uint32) {
+		placed := placedTileSize(rawId, ts)
+		size = image.Pt(max(size.X, placed.X), max(size.Y, placed.Y))
+	})
+
+	return size
+}
+
+// placedTileSize returns the size of the image of the tile with raw gid
+// rawId once flipped, or nothing for unknown tiles.
+func placedTileSize(rawId uint32, ts *tilesets.Set) image.Point {
+	imgId, flip := tilemaps.DecodeGID(rawId)
+	img, err := ts.Img(imgId)
+	if err != nil {
+		return image.Point{}
+	}
+
+	return image.Pt(flip.Size(img.Bounds().Dx(), img.Bounds().Dy()))
+}
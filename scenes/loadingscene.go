@@ -0,0 +1,124 @@
+package scenes
+
+import (
+	"fmt"
+	"image/color"
+
+	ebiten "github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// size of the progress bar in pixels
+const (
+	progressBarHeight = 16
+	progressBarWidth  = 320
+)
+
+// LoadingScene runs the FirstLoad of another scene, or whatever else takes
+// long to load, on a goroutine and shows its progress meanwhile. Once
+// loading is done it switches to the scene, or shows why it couldn't load.
+type LoadingScene struct {
+	back     SceneId    // the scene going back from the error screen leads to
+	done     chan error // receives the result of loading
+	err      error      // why loading failed, nil while loading
+	next     SceneId    // the scene being loaded
+	progress *Progress
+}
+
+func NewLoadingScene() *LoadingScene {
+	return &LoadingScene{}
+}
+
+// Load starts loading scene, whose id is next, in the background.
+func (s *LoadingScene) Load(scene Scene, next SceneId) {
+	s.Run(scene.FirstLoad, next, StartSceneId)
+}
+
+// Run starts load in the background, e.g. a map the game scene warps to,
+// switching to next once it's done. If it fails, going back from the error
+// screen leads to back.
+func (s *LoadingScene) Run(load func(progress *Progress) error, next, back SceneId) {
+	done := make(chan error, 1)
+	progress := &Progress{}
+	go func() {
+		err := load(progress)
+		if err == nil {
+			progress.Done()
+		}
+		done <- err
+	}()
+
+	s.back = back
+	s.done = done
+	s.err = nil
+	s.next = next
+	s.progress = progress
+}
+
+// Fail shows the error screen for err, for scenes loading in the background
+// while they run. Going back from it leads to back.
+func (s *LoadingScene) Fail(err error, back SceneId) {
+	s.back = back
+	s.done = nil
+	s.err = err
+}
+
+func (s *LoadingScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{0, 0, 0, 255})
+
+	if s.err != nil {
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("Loading failed: %v\n\nPress enter to go back, Q to quit.", s.err))
+		return
+	}
+
+	step, fraction := s.progress.Status()
+
+	x := float32(screen.Bounds().Dx()-progressBarWidth) / 2
+	y := float32(screen.Bounds().Dy()-progressBarHeight) / 2
+	vector.DrawFilledRect(screen, x, y, progressBarWidth*float32(fraction), progressBarHeight, color.RGBA{120, 180, 255, 255}, false)
+	vector.StrokeRect(screen, x, y, progressBarWidth, progressBarHeight, 1.0, color.RGBA{255, 255, 255, 255}, false)
+
+	ebitenutil.DebugPrintAt(screen, "Loading "+step, int(x), int(y)+progressBarHeight+4)
+}
+
+// FirstLoad does nothing, the loading scene has nothing to load.
+func (s *LoadingScene) FirstLoad(progress *Progress) error {
+	return nil
+}
+
+func (s *LoadingScene) IsLoaded() bool {
+	return true
+}
+
+func (s *LoadingScene) OnEnter() {
+}
+
+func (s *LoadingScene) OnExit() {
+}
+
+func (s *LoadingScene) Update() SceneId {
+	if s.err != nil {
+		if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
+			return ExitSceneId
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+			return s.back
+		}
+		return LoadingSceneId
+	}
+
+	select {
+	case err := <-s.done:
+		if err != nil {
+			s.err = err
+			return LoadingSceneId
+		}
+		return s.next
+	default:
+		return LoadingSceneId
+	}
+}
+
+var _ Scene = (*LoadingScene)(nil)
@@ -21,7 +21,9 @@ func (s *PauseScene) Draw(screen *ebiten.Image) {
 	ebitenutil.DebugPrint(screen, "Press enter to unpause.")
 }
 
-func (s *PauseScene) FirstLoad() {
+func (s *PauseScene) FirstLoad(progress *Progress) error {
+	s.loaded = true
+	return nil
 }
 
 func (s *PauseScene) IsLoaded() bool {
@@ -0,0 +1,53 @@
+package scenes
+
+import "sync"
+
+// Progress tracks how far a scene got in its FirstLoad. The scene updates it
+// from the goroutine it loads on while the loading scene draws it.
+type Progress struct {
+	done  int
+	mu    sync.Mutex
+	step  string
+	total int
+}
+
+// Expect adds steps to the number of steps loading takes.
+func (p *Progress) Expect(steps int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.total += steps
+}
+
+// Step tells what's being loaded now, which means the step before it is
+// done.
+func (p *Progress) Step(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.step != "" {
+		p.done++
+	}
+	p.step = name
+}
+
+// Done counts every step as done, once loading finished.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done = p.total
+}
+
+// Status returns what's being loaded and how much of the loading is done,
+// from 0 to 1.
+func (p *Progress) Status() (string, float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.total <= 0 {
+		return p.step, 0
+	}
+
+	return p.step, min(float64(p.done)/float64(p.total), 1)
+}
@@ -6,6 +6,7 @@ type SceneId uint
 
 const (
 	GameSceneId SceneId = iota
+	LoadingSceneId
 	PauseSceneId
 	StartSceneId
 	ExitSceneId
@@ -13,7 +14,10 @@ const (
 
 type Scene interface {
 	Draw(screen *ebiten.Image)
-	FirstLoad()
+	// FirstLoad loads what the scene needs before it's entered the first
+	// time. It runs on a goroutine of its own while the loading scene shows
+	// progress, so it mustn't touch anything the active scene uses.
+	FirstLoad(progress *Progress) error
 	IsLoaded() bool
 	OnEnter()
 	OnExit()
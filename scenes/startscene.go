@@ -21,7 +21,9 @@ func (s *StartScene) Draw(screen *ebiten.Image) {
 	ebitenutil.DebugPrint(screen, "Press enter to start")
 }
 
-func (s *StartScene) FirstLoad() {
+func (s *StartScene) FirstLoad(progress *Progress) error {
+	s.loaded = true
+	return nil
 }
 
 func (s *StartScene) IsLoaded() bool {
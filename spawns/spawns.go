@@ -0,0 +1,60 @@
+package spawns
+
+import (
+	"fmt"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+)
+
+// classes of the spawn points designers can place in a map
+const (
+	EnemySkeleton = "enemy.skeleton"
+	PlayerSpawn   = "player_spawn"
+	PotionHeart   = "potion.heart"
+)
+
+// Classes lists every class above, for tools checking maps outside of the
+// game.
+var Classes = []string{EnemySkeleton, PlayerSpawn, PotionHeart}
+
+// Factory turns a spawn point placed in a map into a game entity.
+type Factory func(obj *objects.ObjectJSON) error
+
+type Registry struct {
+	factories map[string]Factory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]Factory),
+	}
+}
+
+func (r *Registry) Has(class string) bool {
+	_, exists := r.factories[class]
+	return exists
+}
+
+// Register makes factory spawn the points of class. Registering a class twice
+// is a programming error, so it panics rather than replacing the first
+// factory.
+func (r *Registry) Register(class string, factory Factory) {
+	if r.Has(class) {
+		panic(fmt.Sprintf("spawns: class %q registered twice", class))
+	}
+
+	r.factories[class] = factory
+}
+
+func (r *Registry) Spawn(obj *objects.ObjectJSON) error {
+	factory, exists := r.factories[obj.ClassName()]
+	if !exists {
+		return fmt.Errorf("object %d: unknown spawn class %q", obj.Id, obj.ClassName())
+	}
+
+	if err := factory(obj); err != nil {
+		return fmt.Errorf("object %d: %w", obj.Id, err)
+	}
+
+	return nil
+}
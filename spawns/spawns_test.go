@@ -0,0 +1,70 @@
+package spawns
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+)
+
+func TestSpawn(t *testing.T) {
+	errBlocked := errors.New("blocked")
+
+	spawned := make([]int, 0)
+	registry := NewRegistry()
+	registry.Register(PotionHeart, func(obj *objects.ObjectJSON) error {
+		spawned = append(spawned, obj.Id)
+		return nil
+	})
+	registry.Register(EnemySkeleton, func(obj *objects.ObjectJSON) error {
+		return errBlocked
+	})
+
+	tests := []struct {
+		name    string
+		obj     *objects.ObjectJSON
+		wantErr string
+		wantIs  error
+	}{
+		{"known class", &objects.ObjectJSON{Id: 1, Class: PotionHeart, Point: true}, "", nil},
+		{"unknown class", &objects.ObjectJSON{Id: 2, Class: "enemy.dragon", Point: true}, `object 2: unknown spawn class "enemy.dragon"`, nil},
+		{"factory error", &objects.ObjectJSON{Id: 3, Class: EnemySkeleton, Point: true}, "object 3: blocked", errBlocked},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := registry.Spawn(test.obj)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != test.wantErr {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+			if test.wantIs != nil && !errors.Is(err, test.wantIs) {
+				t.Errorf("error %v doesn't wrap %v", err, test.wantIs)
+			}
+		})
+	}
+
+	if len(spawned) != 1 || spawned[0] != 1 {
+		t.Errorf("spawned %v, want [1]", spawned)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(PlayerSpawn, func(obj *objects.ObjectJSON) error { return nil })
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, PlayerSpawn) {
+			t.Errorf("got panic %q, want one naming %q", msg, PlayerSpawn)
+		}
+	}()
+	registry.Register(PlayerSpawn, func(obj *objects.ObjectJSON) error { return nil })
+}
@@ -0,0 +1,128 @@
+package tilemaps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// layer data encodings
+const (
+	Base64 = "base64"
+	CSV    = "csv"
+)
+
+// layer data compressions
+const (
+	Gzip = "gzip"
+	Zlib = "zlib"
+	Zstd = "zstd"
+)
+
+// decodeData turns the "data" of a layer into gids. Plain layers store a JSON
+// array; base64 layers store a string holding the gids as little endian
+// uint32s, optionally compressed.
+func decodeData(raw json.RawMessage, encoding, compression string) ([]uint32, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var data []uint32
+	switch encoding {
+	case "", CSV:
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	case Base64:
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, err
+	}
+
+	return decodeBase64(encoded, compression)
+}
+
+// decodeCSV reads the comma separated gids TMX files store.
+func decodeCSV(text string) ([]uint32, error) {
+	fields := strings.Split(text, ",")
+	data := make([]uint32, 0, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		gid, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, uint32(gid))
+	}
+
+	return data, nil
+}
+
+func decodeBase64(encoded, compression string) ([]uint32, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = bytes.NewReader(compressed)
+	switch compression {
+	case "":
+	case Gzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case Zlib:
+		zlibReader, err := zlib.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer zlibReader.Close()
+		reader = zlibReader
+	case Zstd:
+		zstdReader, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded)%4 != 0 {
+		return nil, fmt.Errorf("decoded data is %d bytes, not a multiple of 4", len(decoded))
+	}
+
+	data := make([]uint32, len(decoded)/4)
+	for i := range data {
+		data[i] = binary.LittleEndian.Uint32(decoded[i*4:])
+	}
+
+	return data, nil
+}
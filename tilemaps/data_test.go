@@ -0,0 +1,127 @@
+package tilemaps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var fixtureData = []uint32{
+	1, 2, 3, 4,
+	246, 0, 0, 573,
+	uint32(FlipHorizontal) | 155, 156, uint32(FlipDiagonal) | 157, 0,
+}
+
+func TestLayerDataFixtures(t *testing.T) {
+	fixtures := []string{
+		"testdata/layer_csv.json",
+		"testdata/layer_base64.json",
+		"testdata/layer_gzip.json",
+		"testdata/layer_zlib.json",
+		"testdata/layer_zstd.json",
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			tileMapJson, err := NewTileMapJSON(os.DirFS("."), fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := tileMapJson.Layers[0].Data
+			if !reflect.DeepEqual(data, fixtureData) {
+				t.Errorf("got %v, want %v", data, fixtureData)
+			}
+		})
+	}
+}
+
+func TestLayerDataRoundTrip(t *testing.T) {
+	for name, compression := range map[string]string{"none": "", Gzip: Gzip, Zlib: Zlib, Zstd: Zstd} {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := encodeData(fixtureData, compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := decodeData([]byte(`"`+encoded+`"`), Base64, compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(data, fixtureData) {
+				t.Errorf("got %v, want %v", data, fixtureData)
+			}
+		})
+	}
+}
+
+func TestLayerDataErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		encoding    string
+		compression string
+	}{
+		{"unknown encoding", `"AAAA"`, "hex", ""},
+		{"unknown compression", `"AAAA"`, Base64, "lz4"},
+		{"bad base64", `"not base64!"`, Base64, ""},
+		{"truncated gid", `"AAA="`, Base64, ""},
+		{"not compressed", `"AQAAAA=="`, Base64, Zlib},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decodeData([]byte(test.raw), test.encoding, test.compression); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// encodeData is the inverse of decodeData for base64 layers.
+func encodeData(data []uint32, compression string) (string, error) {
+	raw := make([]byte, len(data)*4)
+	for i, gid := range data {
+		binary.LittleEndian.PutUint32(raw[i*4:], gid)
+	}
+
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch compression {
+	case "":
+		buf.Write(raw)
+	case Gzip:
+		writer = gzip.NewWriter(&buf)
+	case Zlib:
+		writer = zlib.NewWriter(&buf)
+	case Zstd:
+		zstdWriter, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return "", err
+		}
+		writer = zstdWriter
+	default:
+		return "", fmt.Errorf("unsupported compression %q", compression)
+	}
+
+	if writer != nil {
+		if _, err := writer.Write(raw); err != nil {
+			return "", err
+		}
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,111 @@
+package tilemaps
+
+import "fmt"
+
+// TileChange describes a tile replaced through TileMapJSON.SetTile.
+type TileChange struct {
+	Layer string // name of the layer the tile is on
+	New   uint32 // raw gids, see DecodeGID
+	Old   uint32
+	X     int
+	Y     int
+}
+
+// Layer returns the first layer named name, looking into group layers too,
+// or nil if there's none. Edits to it change the map.
+func (t *TileMapJSON) Layer(name string) *TileMapLayerJSON {
+	return findLayer(t.Layers, name)
+}
+
+func findLayer(layers []TileMapLayerJSON, name string) *TileMapLayerJSON {
+	for i := range layers {
+		if layers[i].Name == name {
+			return &layers[i]
+		}
+
+		if layer := findLayer(layers[i].Layers, name); layer != nil {
+			return layer
+		}
+	}
+
+	return nil
+}
+
+// Tile returns the raw gid at tile x, y of the tile layer named layerName.
+func (t *TileMapJSON) Tile(layerName string, x, y int) (uint32, error) {
+	layer, err := t.tileLayer(layerName)
+	if err != nil {
+		return 0, err
+	}
+
+	return layer.Tile(x, y), nil
+}
+
+// SetTile places the tile with raw gid rawId at tile x, y of the tile layer
+// named layerName, or clears it if rawId is 0. If that changes the tile, the
+// listeners registered with OnTileChange are told about it.
+func (t *TileMapJSON) SetTile(layerName string, x, y int, rawId uint32) error {
+	layer, err := t.tileLayer(layerName)
+	if err != nil {
+		return err
+	}
+
+	old := layer.Tile(x, y)
+	if err := layer.SetTile(x, y, rawId); err != nil {
+		return err
+	}
+
+	if old == rawId {
+		return nil
+	}
+
+	change := TileChange{
+		Layer: layerName,
+		New:   rawId,
+		Old:   old,
+		X:     x,
+		Y:     y,
+	}
+	for _, listener := range t.listeners {
+		listener.fn(change)
+	}
+
+	return nil
+}
+
+// tileListener wraps a listener so it can be told apart from the others when
+// it's unregistered, which funcs can't be.
+type tileListener struct {
+	fn func(TileChange)
+}
+
+// OnTileChange registers fn to be called after every tile SetTile changes,
+// in the order the listeners were registered. It returns a func that
+// unregisters fn again, which does nothing once it's done so.
+func (t *TileMapJSON) OnTileChange(fn func(TileChange)) func() {
+	listener := &tileListener{fn: fn}
+	t.listeners = append(t.listeners, listener)
+
+	return func() {
+		for i, l := range t.listeners {
+			if l == listener {
+				// a copy, so a SetTile telling the listeners keeps going
+				// through the ones it started with
+				t.listeners = append(t.listeners[:i:i], t.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (t *TileMapJSON) tileLayer(name string) (*TileMapLayerJSON, error) {
+	layer := t.Layer(name)
+	if layer == nil {
+		return nil, fmt.Errorf("no layer named %q", name)
+	}
+	if layer.Type != TileLayer {
+		return nil, fmt.Errorf("layer %q is a %s, not a %s", name, layer.Type, TileLayer)
+	}
+
+	return layer, nil
+}
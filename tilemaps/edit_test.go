@@ -0,0 +1,46 @@
+package tilemaps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetTileListeners(t *testing.T) {
+	tileMap := TileMapJSON{
+		Layers: []TileMapLayerJSON{{Type: TileLayer, Name: "ground", Width: 2, Height: 1, Data: []uint32{1, 2}}},
+	}
+
+	got := make([]string, 0)
+	var unregisterFirst func()
+	unregisterFirst = tileMap.OnTileChange(func(change TileChange) {
+		got = append(got, "first")
+		// unregistering while being told must not skip the next listener
+		unregisterFirst()
+	})
+	unregisterSecond := tileMap.OnTileChange(func(change TileChange) {
+		got = append(got, "second")
+		if change != (TileChange{Layer: "ground", New: 3, Old: 2, X: 1, Y: 0}) {
+			t.Errorf("got change %+v", change)
+		}
+	})
+
+	if err := tileMap.SetTile("ground", 1, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	// unchanged tiles aren't reported
+	if err := tileMap.SetTile("ground", 1, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got calls %v, want %v", got, want)
+	}
+
+	unregisterSecond()
+	unregisterSecond()
+	if err := tileMap.SetTile("ground", 0, 0, 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got calls %v after unregistering every listener", got)
+	}
+}
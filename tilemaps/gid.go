@@ -0,0 +1,60 @@
+package tilemaps
+
+import "image"
+
+// Tiled stores how a placed tile is flipped in the top bits of its gid.
+const (
+	FlipHorizontal Flip = 0x80000000
+	FlipVertical   Flip = 0x40000000
+	FlipDiagonal   Flip = 0x20000000
+	// only used by hexagonal maps, where it rotates the tile by 120 degrees
+	RotateHex120 Flip = 0x10000000
+
+	flipMask = FlipHorizontal | FlipVertical | FlipDiagonal | RotateHex120
+)
+
+type Flip uint32
+
+// DecodeGID splits a gid from layer data into the tile's global id and the
+// flags it was stored with.
+func DecodeGID(raw uint32) (int, Flip) {
+	return int(raw &^ uint32(flipMask)), Flip(raw) & flipMask
+}
+
+func (f Flip) Diagonal() bool {
+	return f&FlipDiagonal != 0
+}
+
+func (f Flip) Horizontal() bool {
+	return f&FlipHorizontal != 0
+}
+
+func (f Flip) Vertical() bool {
+	return f&FlipVertical != 0
+}
+
+// Size returns the dimensions of a w x h tile once flipped; a diagonal flip
+// swaps them.
+func (f Flip) Size(w, h int) (int, int) {
+	if f.Diagonal() {
+		return h, w
+	}
+	return w, h
+}
+
+// Rect flips r, given relative to the top left corner of a w x h tile, the
+// same way the tile itself is flipped. Like Tiled, the diagonal flip is
+// applied first, then the horizontal and vertical ones.
+func (f Flip) Rect(r image.Rectangle, w, h int) image.Rectangle {
+	if f.Diagonal() {
+		r = image.Rect(r.Min.Y, r.Min.X, r.Max.Y, r.Max.X)
+		w, h = h, w
+	}
+	if f.Horizontal() {
+		r = image.Rect(w-r.Max.X, r.Min.Y, w-r.Min.X, r.Max.Y)
+	}
+	if f.Vertical() {
+		r = image.Rect(r.Min.X, h-r.Max.Y, r.Max.X, h-r.Min.Y)
+	}
+	return r
+}
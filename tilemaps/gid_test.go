@@ -0,0 +1,53 @@
+package tilemaps
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDecodeGID(t *testing.T) {
+	gid, flip := DecodeGID(uint32(FlipHorizontal|FlipDiagonal) | 42)
+	if gid != 42 {
+		t.Errorf("got gid %d, want 42", gid)
+	}
+	if !flip.Horizontal() || flip.Vertical() || !flip.Diagonal() {
+		t.Errorf("got flags %#x, want horizontal and diagonal", uint32(flip))
+	}
+}
+
+func TestFlipRect(t *testing.T) {
+	// a collider in the top left quarter of a tile wider than it's tall
+	const w, h = 32, 16
+	r := image.Rect(2, 1, 6, 4)
+
+	tests := []struct {
+		name     string
+		flip     Flip
+		want     image.Rectangle
+		wantSize image.Point
+	}{
+		{"none", 0, image.Rect(2, 1, 6, 4), image.Pt(32, 16)},
+		{"horizontal", FlipHorizontal, image.Rect(26, 1, 30, 4), image.Pt(32, 16)},
+		{"vertical", FlipVertical, image.Rect(2, 12, 6, 15), image.Pt(32, 16)},
+		{"horizontal vertical", FlipHorizontal | FlipVertical, image.Rect(26, 12, 30, 15), image.Pt(32, 16)},
+		{"diagonal", FlipDiagonal, image.Rect(1, 2, 4, 6), image.Pt(16, 32)},
+		// rotated 90 degrees clockwise
+		{"diagonal horizontal", FlipDiagonal | FlipHorizontal, image.Rect(12, 2, 15, 6), image.Pt(16, 32)},
+		// rotated 90 degrees counterclockwise
+		{"diagonal vertical", FlipDiagonal | FlipVertical, image.Rect(1, 26, 4, 30), image.Pt(16, 32)},
+		{"diagonal horizontal vertical", FlipDiagonal | FlipHorizontal | FlipVertical, image.Rect(12, 26, 15, 30), image.Pt(16, 32)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.flip.Rect(r, w, h); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+
+			fw, fh := test.flip.Size(w, h)
+			if got := image.Pt(fw, fh); got != test.wantSize {
+				t.Errorf("got size %v, want %v", got, test.wantSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package tilemaps
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+const (
+	Group       = "group"
+	ImageLayer  = "imagelayer"
+	ObjectGroup = "objectgroup"
+	TileLayer   = "tilelayer"
+)
+
+// objects drawn in the object layer with this name block movement
+const CollisionLayerName = "collisions"
+
+// tiles of the tile layer with this name are drawn sorted by depth together
+// with the sprites, so sprites can walk behind them
+const DepthLayerName = "objects"
+
+// ChunkJSON is a rectangle of tiles of an infinite map's layer. X and Y are
+// in tiles and can be negative.
+type ChunkJSON struct {
+	Data   []uint32 `json:"-"` // gids with flip flags, see DecodeGID
+	Height int      `json:"height"`
+	Width  int      `json:"width"`
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+}
+
+func (c *ChunkJSON) Bounds() image.Rectangle {
+	return image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height)
+}
+
+type TileMapLayerJSON struct {
+	Chunks      []*ChunkJSON          `json:"chunks"` // only in infinite maps, instead of Data
+	Compression string                `json:"compression"`
+	Data        []uint32              `json:"-"` // gids with flip flags, see DecodeGID
+	Encoding    string                `json:"encoding"`
+	Height      int                   `json:"height"`
+	Image       string                `json:"image"`  // only in image layers, relative to the map file
+	Layers      []TileMapLayerJSON    `json:"layers"` // only in group layers
+	Name        string                `json:"name"`
+	Objects     []*objects.ObjectJSON `json:"objects"`
+	OffsetX     float64               `json:"offsetx"`
+	OffsetY     float64               `json:"offsety"`
+	Opacity     float64               `json:"opacity"`
+	ParallaxX   float64               `json:"parallaxx"`
+	ParallaxY   float64               `json:"parallaxy"`
+	Properties  properties.Properties `json:"properties"`
+	RepeatX     bool                  `json:"repeatx"` // image layers tile their image across the view
+	RepeatY     bool                  `json:"repeaty"`
+	TintColor   properties.Color      `json:"tintcolor"` // multiplied with the layer's tiles
+	Type        string                `json:"type"`
+	Visible     bool                  `json:"visible"`
+	Width       int                   `json:"width"`
+}
+
+// defaultLayer holds the values Tiled leaves out when they're unchanged.
+func defaultLayer() TileMapLayerJSON {
+	return TileMapLayerJSON{
+		Opacity:   1,
+		ParallaxX: 1,
+		ParallaxY: 1,
+		TintColor: properties.Color{R: 255, G: 255, B: 255, A: 255},
+		Visible:   true,
+	}
+}
+
+// inherit returns a copy of the layer as seen through the group holding it.
+// It's only visible if the group is, their opacities, tints and parallax
+// factors multiply and their offsets add up.
+func (l TileMapLayerJSON) inherit(group *TileMapLayerJSON) TileMapLayerJSON {
+	l.OffsetX += group.OffsetX
+	l.OffsetY += group.OffsetY
+	l.Opacity *= group.Opacity
+	l.ParallaxX *= group.ParallaxX
+	l.ParallaxY *= group.ParallaxY
+	l.TintColor = properties.Color{
+		R: uint8(uint16(l.TintColor.R) * uint16(group.TintColor.R) / 255),
+		G: uint8(uint16(l.TintColor.G) * uint16(group.TintColor.G) / 255),
+		B: uint8(uint16(l.TintColor.B) * uint16(group.TintColor.B) / 255),
+		A: uint8(uint16(l.TintColor.A) * uint16(group.TintColor.A) / 255),
+	}
+	l.Visible = l.Visible && group.Visible
+
+	return l
+}
+
+// Offset returns the layer's pixel offset rounded to whole pixels, for
+// placing colliders and spawn points.
+func (l *TileMapLayerJSON) Offset() image.Point {
+	return image.Pt(int(math.Round(l.OffsetX)), int(math.Round(l.OffsetY)))
+}
+
+func (l *TileMapLayerJSON) UnmarshalJSON(b []byte) error {
+	// aliases drop the method so json.Unmarshal doesn't recurse into it
+	type alias TileMapLayerJSON
+	type chunkAlias ChunkJSON
+	type chunk struct {
+		chunkAlias
+		Data json.RawMessage `json:"data"`
+	}
+	layer := struct {
+		*alias
+		Chunks []chunk         `json:"chunks"`
+		Data   json.RawMessage `json:"data"`
+	}{alias: (*alias)(l)}
+
+	*l = defaultLayer()
+	if err := json.Unmarshal(b, &layer); err != nil {
+		return err
+	}
+
+	data, err := decodeData(layer.Data, l.Encoding, l.Compression)
+	if err != nil {
+		return fmt.Errorf("layer %q: %w", l.Name, err)
+	}
+
+	if len(data) > 0 && l.Width <= 0 {
+		return fmt.Errorf("layer %q: has data but a width of %d", l.Name, l.Width)
+	}
+
+	l.Data = data
+
+	l.Chunks = nil
+	for _, c := range layer.Chunks {
+		chunkJSON := ChunkJSON(c.chunkAlias)
+		chunkJSON.Data, err = decodeData(c.Data, l.Encoding, l.Compression)
+		if err != nil {
+			return fmt.Errorf("layer %q chunk (%d, %d): %w", l.Name, chunkJSON.X, chunkJSON.Y, err)
+		}
+
+		if len(chunkJSON.Data) > 0 && chunkJSON.Width <= 0 {
+			return fmt.Errorf("layer %q chunk (%d, %d): has data but a width of %d", l.Name, chunkJSON.X, chunkJSON.Y, chunkJSON.Width)
+		}
+
+		l.Chunks = append(l.Chunks, &chunkJSON)
+	}
+
+	return nil
+}
+
+// Bounds returns the area the layer's tiles can occupy, in tiles.
+func (l *TileMapLayerJSON) Bounds() image.Rectangle {
+	if l.Chunks == nil {
+		return image.Rect(0, 0, l.Width, l.Height)
+	}
+
+	bounds := image.Rectangle{}
+	for _, chunk := range l.Chunks {
+		bounds = bounds.Union(chunk.Bounds())
+	}
+
+	return bounds
+}
+
+// Tile returns the raw gid at tile x, y, or 0 if the layer has no tile
+// there.
+func (l *TileMapLayerJSON) Tile(x, y int) uint32 {
+	tile := l.tileRef(x, y)
+	if tile == nil {
+		return 0
+	}
+
+	return *tile
+}
+
+// SetTile places the tile with raw gid rawId at tile x, y, or clears it if
+// rawId is 0. It fails outside of the layer's data; the chunks of infinite
+// maps don't grow.
+func (l *TileMapLayerJSON) SetTile(x, y int, rawId uint32) error {
+	tile := l.tileRef(x, y)
+	if tile == nil {
+		return fmt.Errorf("layer %q: tile (%d, %d) is outside its data", l.Name, x, y)
+	}
+
+	*tile = rawId
+	return nil
+}
+
+// tileRef returns where the layer's data holds tile x, y, or nil if it
+// doesn't.
+func (l *TileMapLayerJSON) tileRef(x, y int) *uint32 {
+	pt := image.Pt(x, y)
+	for _, chunk := range l.Chunks {
+		if !pt.In(chunk.Bounds()) {
+			continue
+		}
+
+		idx := (y-chunk.Y)*chunk.Width + x - chunk.X
+		if idx < len(chunk.Data) {
+			return &chunk.Data[idx]
+		}
+		return nil
+	}
+
+	if x < 0 || x >= l.Width || y < 0 {
+		return nil
+	}
+
+	idx := y*l.Width + x
+	if idx < len(l.Data) {
+		return &l.Data[idx]
+	}
+	return nil
+}
+
+// EachTile calls fn with the tile position and raw gid of every non-empty
+// tile of the layer, whether its data is chunked or not.
+func (l *TileMapLayerJSON) EachTile(fn func(x, y int, rawId uint32)) {
+	for imgIdx, rawId := range l.Data {
+		if rawId != 0 {
+			fn(imgIdx%l.Width, imgIdx/l.Width, rawId)
+		}
+	}
+
+	for _, chunk := range l.Chunks {
+		for imgIdx, rawId := range chunk.Data {
+			if rawId != 0 {
+				fn(chunk.X+imgIdx%chunk.Width, chunk.Y+imgIdx/chunk.Width, rawId)
+			}
+		}
+	}
+}
+
+// EachTileIn is EachTile for the tiles inside area only, in tiles. Rows and
+// chunks outside of it aren't looked at.
+func (l *TileMapLayerJSON) EachTileIn(area image.Rectangle, fn func(x, y int, rawId uint32)) {
+	if l.Width > 0 {
+		r := area.Intersect(image.Rect(0, 0, l.Width, len(l.Data)/l.Width))
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if rawId := l.Data[y*l.Width+x]; rawId != 0 {
+					fn(x, y, rawId)
+				}
+			}
+		}
+	}
+
+	for _, chunk := range l.Chunks {
+		r := area.Intersect(chunk.Bounds())
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				idx := (y-chunk.Y)*chunk.Width + x - chunk.X
+				if idx >= len(chunk.Data) {
+					break
+				}
+				if rawId := chunk.Data[idx]; rawId != 0 {
+					fn(x, y, rawId)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package tilemaps
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestEachTileIn(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer TileMapLayerJSON
+		area  image.Rectangle
+		want  []image.Point
+	}{
+		{
+			name:  "finite",
+			layer: TileMapLayerJSON{Width: 3, Height: 2, Data: []uint32{1, 2, 3, 4, 0, 6}},
+			area:  image.Rect(1, -1, 5, 5),
+			want:  []image.Point{{1, 0}, {2, 0}, {2, 1}},
+		},
+		{
+			name: "chunked",
+			layer: TileMapLayerJSON{Chunks: []*ChunkJSON{
+				{X: -2, Y: -2, Width: 2, Height: 2, Data: []uint32{1, 2, 3, 4}},
+				{X: 0, Y: -2, Width: 2, Height: 2, Data: []uint32{5, 6, 7, 8}},
+			}},
+			area: image.Rect(-1, -1, 1, 0),
+			want: []image.Point{{-1, -1}, {0, -1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]image.Point, 0)
+			test.layer.EachTileIn(test.area, func(x, y int, rawId uint32) {
+				got = append(got, image.Pt(x, y))
+			})
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got tiles %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTilesIn(t *testing.T) {
+	tileMap := TileMapJSON{TileWidth: 16, TileHeight: 8}
+
+	got := tileMap.TilesIn(image.Rect(-1, 0, 17, 9))
+	if want := image.Rect(-1, 0, 2, 2); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = tileMap.TilesIn(image.Rect(-32, -16, 0, 0))
+	if want := image.Rect(-2, -2, 0, 0); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
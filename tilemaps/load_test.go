@@ -0,0 +1,121 @@
+package tilemaps
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var loadFS = fstest.MapFS{
+	"maps/town.json": {Data: []byte(`{
+		"width": 2, "height": 2, "tilewidth": 16, "tileheight": 16,
+		"layers": [{"type": "tilelayer", "name": "ground", "width": 2, "height": 2, "data": [1, 2, 3, 5]}],
+		"tilesets": [
+			{"firstgid": 1, "source": "tilesets/floor.json"},
+			{"firstgid": 5, "name": "trees", "tilewidth": 16, "tileheight": 16, "tilecount": 1, "columns": 1, "image": "../images/trees.png"}
+		]
+	}`)},
+	"maps/town.tmx": {Data: []byte(`<map width="2" height="2" tilewidth="16" tileheight="16">
+		<tileset firstgid="1" source="tilesets/floor.tsx"/>
+		<layer name="ground" width="2" height="2"><data encoding="csv">1,2,3,4</data></layer>
+	</map>`)},
+	"maps/broken.json": {Data: []byte(`{
+		"width": 1, "height": 1,
+		"layers": [{"type": "tilelayer", "name": "ground", "width": 1, "height": 1, "data": [1]}],
+		"tilesets": [{"firstgid": 1, "source": "tilesets/missing.json"}]
+	}`)},
+	"maps/tilesets/floor.json": {Data: []byte(`{
+		"tilewidth": 16, "tileheight": 16, "tilecount": 4, "columns": 2, "image": "../../images/floor.png"
+	}`)},
+	"maps/tilesets/floor.tsx": {Data: []byte(`<tileset tilewidth="16" tileheight="16" tilecount="4" columns="2">
+		<image source="..\..\images\floor.png" width="32" height="32"/>
+	</tileset>`)},
+}
+
+func TestLoadMapFromFS(t *testing.T) {
+	for _, mapPath := range []string{"maps/town.json", "maps/town.tmx"} {
+		t.Run(mapPath, func(t *testing.T) {
+			tileMapJson, err := NewTileMap(loadFS, mapPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := tileMapJson.Layer("ground").Data
+			if len(data) != 4 || data[0] != 1 {
+				t.Errorf("got data %v", data)
+			}
+
+			tilesetJson, dir, err := tileMapJson.Tilesets[0].Load(loadFS, mapPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dir != "maps/tilesets" {
+				t.Errorf("got dir %q, want %q", dir, "maps/tilesets")
+			}
+
+			imgPath := ResolvePath(dir, tilesetJson.Path)
+			if imgPath != "images/floor.png" {
+				t.Errorf("got image path %q, want %q", imgPath, "images/floor.png")
+			}
+		})
+	}
+}
+
+func TestLoadEmbeddedTileset(t *testing.T) {
+	tileMapJson, err := NewTileMap(loadFS, "maps/town.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tilesetJson, dir, err := tileMapJson.Tilesets[1].Load(loadFS, "maps/town.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tilesetJson != tileMapJson.Tilesets[1].Embedded {
+		t.Error("got a tileset other than the embedded one")
+	}
+
+	// paths of embedded tilesets are relative to the map
+	imgPath := ResolvePath(dir, tilesetJson.Path)
+	if imgPath != "images/trees.png" {
+		t.Errorf("got image path %q, want %q", imgPath, "images/trees.png")
+	}
+}
+
+func TestLoadTilesetFromFS(t *testing.T) {
+	want := TilesetJSON{
+		Columns:     2,
+		ImageHeight: 32,
+		ImageWidth:  32,
+		Path:        `..\..\images\floor.png`,
+		TileCount:   4,
+		TileHeight:  16,
+		Tiles:       []*TileJSON{},
+		TileWidth:   16,
+	}
+
+	tilesetJson, err := NewTilesetJSON(loadFS, "maps/tilesets/floor.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*tilesetJson, want) {
+		t.Errorf("got %+v, want %+v", *tilesetJson, want)
+	}
+}
+
+func TestLoadMissingFiles(t *testing.T) {
+	if _, err := NewTileMap(loadFS, "maps/nowhere.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("loading a missing map: got %v, want %v", err, fs.ErrNotExist)
+	}
+
+	tileMapJson, err := NewTileMap(loadFS, "maps/broken.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := tileMapJson.Tilesets[0].Load(loadFS, "maps/broken.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("loading a missing tileset: got %v, want %v", err, fs.ErrNotExist)
+	}
+}
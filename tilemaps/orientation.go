@@ -0,0 +1,234 @@
+package tilemaps
+
+import (
+	"image"
+	"math"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+)
+
+// map orientations, an empty one is orthogonal
+const (
+	Hexagonal  = "hexagonal"
+	Isometric  = "isometric"
+	Orthogonal = "orthogonal"
+	Staggered  = "staggered"
+)
+
+// staggered and hexagonal maps shift every other row or column, picked by
+// their stagger axis and index
+const (
+	StaggerEven = "even"
+	StaggerOdd  = "odd"
+	StaggerX    = "x"
+	StaggerY    = "y"
+)
+
+// staggerParams holds the measures staggered and hexagonal maps are laid out
+// by. Staggered maps are hexagonal maps whose hexagons have no sides.
+type staggerParams struct {
+	columnWidth int
+	even        bool
+	rowHeight   int
+	sideLengthX int
+	sideLengthY int
+	sideOffsetX int
+	sideOffsetY int
+	staggerX    bool
+	tileHeight  int
+	tileWidth   int
+}
+
+func (t *TileMapJSON) staggerParams() staggerParams {
+	p := staggerParams{
+		even:       t.StaggerIndex == StaggerEven,
+		staggerX:   t.StaggerAxis == StaggerX,
+		tileHeight: t.TileHeight &^ 1,
+		tileWidth:  t.TileWidth &^ 1,
+	}
+
+	if t.Orientation == Hexagonal {
+		if p.staggerX {
+			p.sideLengthX = t.HexSideLength
+		} else {
+			p.sideLengthY = t.HexSideLength
+		}
+	}
+
+	p.sideOffsetX = (p.tileWidth - p.sideLengthX) / 2
+	p.sideOffsetY = (p.tileHeight - p.sideLengthY) / 2
+	p.columnWidth = p.sideOffsetX + p.sideLengthX
+	p.rowHeight = p.sideOffsetY + p.sideLengthY
+
+	return p
+}
+
+// staggered reports whether row or column i, along the stagger axis, is
+// shifted.
+func (p *staggerParams) staggered(i int) bool {
+	return (i&1 == 1) != p.even
+}
+
+// TileToPixel returns the map pixel position of the top left corner of the
+// TileWidth x TileHeight cell of tile x, y. Isometric maps are shifted right
+// so that their leftmost tile starts at x 0.
+func (t *TileMapJSON) TileToPixel(x, y int) image.Point {
+	switch t.Orientation {
+	case Isometric:
+		originX := t.Height * t.TileWidth / 2
+		return image.Pt((x-y)*t.TileWidth/2+originX-t.TileWidth/2, (x+y)*t.TileHeight/2)
+	case Hexagonal, Staggered:
+		p := t.staggerParams()
+		if p.staggerX {
+			pixelY := y * (p.tileHeight + p.sideLengthY)
+			if p.staggered(x) {
+				pixelY += p.rowHeight
+			}
+			return image.Pt(x*p.columnWidth, pixelY)
+		}
+
+		pixelX := x * (p.tileWidth + p.sideLengthX)
+		if p.staggered(y) {
+			pixelX += p.columnWidth
+		}
+		return image.Pt(pixelX, y*p.rowHeight)
+	default:
+		return image.Pt(x*t.TileWidth, y*t.TileHeight)
+	}
+}
+
+// PixelToTile returns the tile whose cell holds the map pixel position px,
+// py. Where the cells of isometric, staggered and hexagonal maps overlap,
+// it's the tile whose shape holds the position.
+func (t *TileMapJSON) PixelToTile(px, py float64) image.Point {
+	tw, th := float64(t.TileWidth), float64(t.TileHeight)
+
+	switch t.Orientation {
+	case Isometric:
+		px -= float64(t.Height) * tw / 2
+		tileX, tileY := px/tw, py/th
+		return image.Pt(int(math.Floor(tileY+tileX)), int(math.Floor(tileY-tileX)))
+	case Hexagonal, Staggered:
+		return t.pixelToStaggeredTile(px, py)
+	default:
+		return image.Pt(int(math.Floor(px/tw)), int(math.Floor(py/th)))
+	}
+}
+
+// pixelToStaggeredTile finds the tile of a staggered or hexagonal map
+// nearest to a position. Every block of two columns and two rows of such a
+// map holds parts of four tiles, whose centers are compared.
+func (t *TileMapJSON) pixelToStaggeredTile(px, py float64) image.Point {
+	p := t.staggerParams()
+
+	if p.staggerX {
+		if p.even {
+			px -= float64(p.tileWidth)
+		} else {
+			px -= float64(p.sideOffsetX)
+		}
+	} else {
+		if p.even {
+			py -= float64(p.tileHeight)
+		} else {
+			py -= float64(p.sideOffsetY)
+		}
+	}
+
+	blockWidth, blockHeight := float64(2*p.columnWidth), float64(2*p.rowHeight)
+	ref := image.Pt(int(math.Floor(px/blockWidth)), int(math.Floor(py/blockHeight)))
+	relX := px - float64(ref.X)*blockWidth
+	relY := py - float64(ref.Y)*blockHeight
+
+	var centers [4][2]float64
+	var offsets [4]image.Point
+	if p.staggerX {
+		ref.X *= 2
+		if p.even {
+			ref.X++
+		}
+
+		left := float64(p.sideLengthX / 2)
+		centerX := left + float64(p.columnWidth)
+		centerY := float64(p.tileHeight / 2)
+		centers = [4][2]float64{
+			{left, centerY},
+			{centerX, centerY - float64(p.rowHeight)},
+			{centerX, centerY + float64(p.rowHeight)},
+			{centerX + float64(p.columnWidth), centerY},
+		}
+		offsets = [4]image.Point{{0, 0}, {1, -1}, {1, 0}, {2, 0}}
+	} else {
+		ref.Y *= 2
+		if p.even {
+			ref.Y++
+		}
+
+		top := float64(p.sideLengthY / 2)
+		centerX := float64(p.tileWidth / 2)
+		centerY := top + float64(p.rowHeight)
+		centers = [4][2]float64{
+			{centerX, top},
+			{centerX - float64(p.columnWidth), centerY},
+			{centerX + float64(p.columnWidth), centerY},
+			{centerX, centerY + float64(p.rowHeight)},
+		}
+		offsets = [4]image.Point{{0, 0}, {-1, 1}, {0, 1}, {0, 2}}
+	}
+
+	// the diamonds of staggered maps are squares once stretched to be as
+	// tall as they're wide, which makes the nearest center the right one
+	scaleY := 1.0
+	if t.Orientation == Staggered && p.tileHeight > 0 {
+		scaleY = float64(p.tileWidth) / float64(p.tileHeight)
+	}
+
+	nearest, minDist := 0, math.Inf(1)
+	for i, center := range centers {
+		dx, dy := center[0]-relX, (center[1]-relY)*scaleY
+		if dist := dx*dx + dy*dy; dist < minDist {
+			nearest, minDist = i, dist
+		}
+	}
+
+	return ref.Add(offsets[nearest])
+}
+
+// ObjectToPixel converts an object position to map pixels. Isometric maps
+// store objects in a space whose axes follow the tile grid, in which every
+// tile is TileHeight pixels square; all other maps store map pixels.
+func (t *TileMapJSON) ObjectToPixel(x, y float64) (float64, float64) {
+	if t.Orientation != Isometric || t.TileHeight == 0 {
+		return x, y
+	}
+
+	tileX, tileY := x/float64(t.TileHeight), y/float64(t.TileHeight)
+	originX := float64(t.Height*t.TileWidth) / 2
+
+	return (tileX-tileY)*float64(t.TileWidth)/2 + originX, (tileX + tileY) * float64(t.TileHeight) / 2
+}
+
+// ObjectBounds returns the bounding box of an object in map pixels. The
+// rectangles of isometric maps are diamonds on screen, so theirs covers the
+// diamond.
+func (t *TileMapJSON) ObjectBounds(object *objects.ObjectJSON) image.Rectangle {
+	bounds := object.Bounds()
+	if t.Orientation != Isometric {
+		return bounds
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range []image.Point{bounds.Min, {bounds.Max.X, bounds.Min.Y}, bounds.Max, {bounds.Min.X, bounds.Max.Y}} {
+		x, y := t.ObjectToPixel(float64(corner.X), float64(corner.Y))
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	return image.Rect(
+		int(math.Floor(minX)),
+		int(math.Floor(minY)),
+		int(math.Ceil(maxX)),
+		int(math.Ceil(maxY)),
+	)
+}
@@ -0,0 +1,88 @@
+package tilemaps
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+// orientedMaps has a map of every orientation and stagger setting.
+var orientedMaps = []TileMapJSON{
+	{Orientation: Orthogonal, TileWidth: 16, TileHeight: 16},
+	{Orientation: Isometric, TileWidth: 32, TileHeight: 16},
+	{Orientation: Staggered, TileWidth: 32, TileHeight: 16, StaggerAxis: StaggerX, StaggerIndex: StaggerOdd},
+	{Orientation: Staggered, TileWidth: 32, TileHeight: 16, StaggerAxis: StaggerX, StaggerIndex: StaggerEven},
+	{Orientation: Staggered, TileWidth: 32, TileHeight: 16, StaggerAxis: StaggerY, StaggerIndex: StaggerOdd},
+	{Orientation: Staggered, TileWidth: 32, TileHeight: 16, StaggerAxis: StaggerY, StaggerIndex: StaggerEven},
+	{Orientation: Hexagonal, TileWidth: 32, TileHeight: 28, HexSideLength: 16, StaggerAxis: StaggerX, StaggerIndex: StaggerOdd},
+	{Orientation: Hexagonal, TileWidth: 32, TileHeight: 28, HexSideLength: 16, StaggerAxis: StaggerX, StaggerIndex: StaggerEven},
+	{Orientation: Hexagonal, TileWidth: 28, TileHeight: 32, HexSideLength: 16, StaggerAxis: StaggerY, StaggerIndex: StaggerOdd},
+	{Orientation: Hexagonal, TileWidth: 28, TileHeight: 32, HexSideLength: 16, StaggerAxis: StaggerY, StaggerIndex: StaggerEven},
+}
+
+func orientedMapName(tileMap *TileMapJSON) string {
+	return fmt.Sprintf("%s stagger %s %s", tileMap.Orientation, tileMap.StaggerAxis, tileMap.StaggerIndex)
+}
+
+func TestPixelToTileRoundTrip(t *testing.T) {
+	for _, tileMap := range orientedMaps {
+		tileMap.Width, tileMap.Height = 6, 6
+		t.Run(orientedMapName(&tileMap), func(t *testing.T) {
+			for y := 0; y < tileMap.Height; y++ {
+				for x := 0; x < tileMap.Width; x++ {
+					// the center of a tile's cell is inside its shape
+					cell := tileMap.TileToPixel(x, y)
+					px := float64(cell.X) + float64(tileMap.TileWidth)/2
+					py := float64(cell.Y) + float64(tileMap.TileHeight)/2
+
+					if got := tileMap.PixelToTile(px, py); got != image.Pt(x, y) {
+						t.Errorf("tile (%d, %d): center (%g, %g) is in tile %v", x, y, px, py, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPixelToTileCorners(t *testing.T) {
+	tests := []struct {
+		tileMap TileMapJSON
+		px, py  float64
+		want    image.Point
+	}{
+		// cells of orthogonal maps hold their top left corner only
+		{TileMapJSON{Orientation: Orthogonal, TileWidth: 16, TileHeight: 16}, 16, 16, image.Pt(1, 1)},
+		{TileMapJSON{Orientation: Orthogonal, TileWidth: 16, TileHeight: 16}, 15.9, 15.9, image.Pt(0, 0)},
+		{TileMapJSON{Orientation: Orthogonal, TileWidth: 16, TileHeight: 16}, -0.1, -0.1, image.Pt(-1, -1)},
+		// isometric diamonds end halfway up the sides of their cells, where
+		// the tiles to their left and right begin
+		{TileMapJSON{Orientation: Isometric, Height: 6, TileWidth: 32, TileHeight: 16}, 81, 8, image.Pt(0, 0)},
+		{TileMapJSON{Orientation: Isometric, Height: 6, TileWidth: 32, TileHeight: 16}, 79, 8, image.Pt(-1, 1)},
+		{TileMapJSON{Orientation: Isometric, Height: 6, TileWidth: 32, TileHeight: 16}, 111, 8, image.Pt(0, 0)},
+		{TileMapJSON{Orientation: Isometric, Height: 6, TileWidth: 32, TileHeight: 16}, 113, 8, image.Pt(1, -1)},
+	}
+
+	for _, test := range tests {
+		if got := test.tileMap.PixelToTile(test.px, test.py); got != test.want {
+			t.Errorf("%s (%g, %g): got %v, want %v", test.tileMap.Orientation, test.px, test.py, got, test.want)
+		}
+	}
+}
+
+func TestTilesInHoldsEveryPixel(t *testing.T) {
+	area := image.Rect(41, 19, 121, 93)
+
+	for _, tileMap := range orientedMaps {
+		tileMap.Width, tileMap.Height = 6, 6
+		t.Run(orientedMapName(&tileMap), func(t *testing.T) {
+			tiles := tileMap.TilesIn(area)
+			for py := area.Min.Y; py < area.Max.Y; py++ {
+				for px := area.Min.X; px < area.Max.X; px++ {
+					if tile := tileMap.PixelToTile(float64(px), float64(py)); !tile.In(tiles) {
+						t.Fatalf("pixel (%d, %d) is in tile %v, outside of %v", px, py, tile, tiles)
+					}
+				}
+			}
+		})
+	}
+}
@@ -2,42 +2,306 @@ package tilemaps
 
 import (
 	"encoding/json"
-	"os"
+	"fmt"
+	"image"
+	"io/fs"
 	"path"
+	"strings"
 
-	"github.com/ev-the-dev/rpg-tutorial/tilesets"
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+	"github.com/ev-the-dev/rpg-tutorial/properties"
 )
 
-type TileMapLayerJSON struct {
-	Data   []int  `json:"data"`
-	Height int    `json:"height"`
-	Name   string `json:"name"`
-	Width  int    `json:"width"`
+// TilesetRefJSON is an entry of a map's tileset list. External tilesets only
+// name their file in Source, embedded ones are stored in the map itself.
+type TilesetRefJSON struct {
+	Embedded *TilesetJSON `json:"-"`
+	FirstGid int          `json:"firstgid"`
+	Source   string       `json:"source"`
+}
+
+func (r *TilesetRefJSON) UnmarshalJSON(b []byte) error {
+	// alias drops the method so json.Unmarshal doesn't recurse into it
+	type alias TilesetRefJSON
+	if err := json.Unmarshal(b, (*alias)(r)); err != nil {
+		return err
+	}
+
+	if r.Source != "" {
+		return nil
+	}
+
+	r.Embedded = &TilesetJSON{}
+	return json.Unmarshal(b, r.Embedded)
+}
+
+// Load returns the tileset the entry refers to, reading it from fsys if
+// it's external, together with the directory the paths in it are relative
+// to. mapPath is where the map holding the entry is in fsys.
+func (r *TilesetRefJSON) Load(fsys fs.FS, mapPath string) (*TilesetJSON, string, error) {
+	if r.Embedded != nil {
+		return r.Embedded, path.Dir(mapPath), nil
+	}
+
+	tilesetPath := ResolvePath(path.Dir(mapPath), r.Source)
+	tilesetJson, err := NewTilesetJSON(fsys, tilesetPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return tilesetJson, path.Dir(tilesetPath), nil
+}
+
+// TileShapes tells Colliders the size and collision shapes of the tiles a
+// map's gids refer to, see tilesets.Set.
+type TileShapes interface {
+	Colliders(gid int) ([]image.Rectangle, error)
+	Size(gid int) (image.Point, error)
 }
 
 type TileMapJSON struct {
-	Layers   []TileMapLayerJSON `json:"layers"`
-	Tilesets []map[string]any   `json:"tilesets"`
+	Height          int                   `json:"height"`
+	HexSideLength   int                   `json:"hexsidelength"` // only in hexagonal maps
+	Infinite        bool                  `json:"infinite"`
+	Layers          []TileMapLayerJSON    `json:"layers"`
+	Orientation     string                `json:"orientation"`
+	ParallaxOriginX float64               `json:"parallaxoriginx"` // view center at which parallax layers sit where they're placed
+	ParallaxOriginY float64               `json:"parallaxoriginy"`
+	Properties      properties.Properties `json:"properties"`
+	StaggerAxis     string                `json:"staggeraxis"` // only in staggered and hexagonal maps
+	StaggerIndex    string                `json:"staggerindex"`
+	TileHeight      int                   `json:"tileheight"`
+	Tilesets        []*TilesetRefJSON     `json:"tilesets"`
+	TileWidth       int                   `json:"tilewidth"`
+	Width           int                   `json:"width"`
+
+	listeners []*tileListener // see OnTileChange
+}
+
+// FlatLayers returns the map's layers in drawing order, with every group
+// layer replaced by the layers it holds. Those inherit the visibility,
+// opacity, tint, offset and parallax factor of their groups. The copies share
+// their tile data with the map.
+func (t *TileMapJSON) FlatLayers() []TileMapLayerJSON {
+	return flattenLayers(t.Layers, nil)
+}
+
+func flattenLayers(layers []TileMapLayerJSON, group *TileMapLayerJSON) []TileMapLayerJSON {
+	flat := make([]TileMapLayerJSON, 0, len(layers))
+	for _, layer := range layers {
+		if group != nil {
+			layer = layer.inherit(group)
+		}
+
+		if layer.Type == Group {
+			flat = append(flat, flattenLayers(layer.Layers, &layer)...)
+			continue
+		}
+
+		flat = append(flat, layer)
+	}
+
+	return flat
+}
+
+// Bounds returns the area covered by the map in tiles. Infinite maps can
+// grow in any direction, so theirs is the union of their tile layers.
+func (t *TileMapJSON) Bounds() image.Rectangle {
+	if !t.Infinite {
+		return image.Rect(0, 0, t.Width, t.Height)
+	}
+
+	bounds := image.Rectangle{}
+	for _, layer := range t.FlatLayers() {
+		if layer.Type == TileLayer {
+			bounds = bounds.Union(layer.Bounds())
+		}
+	}
+
+	return bounds
+}
+
+// PixelBounds returns the area covered by the map in pixels. Whatever the
+// orientation, the tiles furthest out are on the edges of the map's tile
+// bounds, so only their cells are measured.
+func (t *TileMapJSON) PixelBounds() image.Rectangle {
+	bounds := t.Bounds()
+	if bounds.Empty() {
+		return image.Rectangle{}
+	}
+
+	cell := func(x, y int) image.Rectangle {
+		pos := t.TileToPixel(x, y)
+		return image.Rect(pos.X, pos.Y, pos.X+t.TileWidth, pos.Y+t.TileHeight)
+	}
+
+	pixelBounds := image.Rectangle{}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		pixelBounds = pixelBounds.Union(cell(x, bounds.Min.Y)).Union(cell(x, bounds.Max.Y-1))
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		pixelBounds = pixelBounds.Union(cell(bounds.Min.X, y)).Union(cell(bounds.Max.X-1, y))
+	}
+
+	return pixelBounds
+}
+
+// TilePosition returns the map pixel position of the top left corner of a
+// tile image placed at tile x, y. Like Tiled, images of any size are
+// anchored to the bottom left corner of their cell.
+func (t *TileMapJSON) TilePosition(x, y int, img image.Rectangle) image.Point {
+	cell := t.TileToPixel(x, y)
+	return image.Pt(cell.X, cell.Y+t.TileHeight-img.Dy())
 }
 
-func (t *TileMapJSON) GenTilesets() ([]tilesets.Tileset, error) {
+// TilesIn returns the tiles whose cells overlap area, given in map pixels.
+// The cells of other orientations than orthogonal aren't lined up with the
+// pixel axes, so for those it's the tiles under the area's corners grown by
+// one tile all around, which holds every tile whose shape the area touches.
+func (t *TileMapJSON) TilesIn(area image.Rectangle) image.Rectangle {
+	if t.Orientation == Isometric || t.Orientation == Staggered || t.Orientation == Hexagonal {
+		tiles := image.Rectangle{}
+		for _, corner := range []image.Point{area.Min, {area.Max.X, area.Min.Y}, {area.Min.X, area.Max.Y}, area.Max} {
+			tile := t.PixelToTile(float64(corner.X), float64(corner.Y))
+			tiles = tiles.Union(image.Rectangle{tile, tile.Add(image.Pt(1, 1))})
+		}
+
+		return tiles.Inset(-1)
+	}
+
+	return image.Rect(
+		floorDiv(area.Min.X, t.TileWidth),
+		floorDiv(area.Min.Y, t.TileHeight),
+		floorDiv(area.Max.X+t.TileWidth-1, t.TileWidth),
+		floorDiv(area.Max.Y+t.TileHeight-1, t.TileHeight),
+	)
+}
+
+// floorDiv divides rounding towards negative infinity, which keeps the tiles
+// left of and above the origin of infinite maps in their cells.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// Colliders returns the bounds of every shape in the collision layers, plus
+// the collision shapes of every placed tile that has them. Points and
+// polylines enclose no area, so they are skipped. Since it resolves every
+// placed tile, it also reports tiles no tileset owns.
+func (t *TileMapJSON) Colliders(ts TileShapes) ([]image.Rectangle, error) {
+	colliders := make([]image.Rectangle, 0)
+	for _, layer := range t.FlatLayers() {
+		switch layer.Type {
+		case ObjectGroup:
+			if layer.Name != CollisionLayerName {
+				continue
+			}
+
+			for _, object := range layer.Objects {
+				if object.HasArea() {
+					colliders = append(colliders, t.ObjectBounds(object).Add(layer.Offset()))
+				}
+			}
+		case TileLayer:
+			var err error
+			layer.EachTile(func(x, y int, rawId uint32) {
+				if err != nil {
+					return
+				}
+
+				var cell []image.Rectangle
+				cell, err = t.cellColliders(ts, &layer, x, y, rawId)
+				colliders = append(colliders, cell...)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return colliders, nil
+}
+
+// TileColliders returns the collision shapes of the tile with raw gid rawId
+// placed at tile x, y of the tile layer named layerName, in map pixels. These
+// are what Colliders returns for that tile.
+func (t *TileMapJSON) TileColliders(ts TileShapes, layerName string, x, y int, rawId uint32) ([]image.Rectangle, error) {
+	for _, layer := range t.FlatLayers() {
+		if layer.Type == TileLayer && layer.Name == layerName {
+			return t.cellColliders(ts, &layer, x, y, rawId)
+		}
+	}
+
+	return nil, fmt.Errorf("no tile layer named %q", layerName)
+}
+
+func (t *TileMapJSON) cellColliders(ts TileShapes, layer *TileMapLayerJSON, x, y int, rawId uint32) ([]image.Rectangle, error) {
+	imgId, flip := DecodeGID(rawId)
+	if imgId == 0 {
+		return nil, nil
+	}
 
-	ts := make([]tilesets.Tileset, 0)
-	for _, tilesetData := range t.Tilesets {
-		tilesetPath := path.Join("assets/maps/", tilesetData["source"].(string))
-		tileset, err := tilesets.NewTileset(tilesetPath, int(tilesetData["firstgid"].(float64)))
-		if err != nil {
-			return nil, err
+	size, err := ts.Size(imgId)
+	if err != nil {
+		return nil, fmt.Errorf("layer %q tile (%d, %d): %w", layer.Name, x, y, err)
+	}
+
+	tileColliders, err := ts.Colliders(imgId)
+	if err != nil {
+		return nil, fmt.Errorf("layer %q gid %d: %w", layer.Name, imgId, err)
+	}
+
+	w, h := size.X, size.Y
+	fw, fh := flip.Size(w, h)
+	pos := t.TilePosition(x, y, image.Rect(0, 0, fw, fh)).Add(layer.Offset())
+
+	colliders := make([]image.Rectangle, 0, len(tileColliders))
+	for _, collider := range tileColliders {
+		colliders = append(colliders, flip.Rect(collider, w, h).Add(pos))
+	}
+
+	return colliders, nil
+}
+
+// SpawnPoints returns every point object that has a class, in layer order,
+// moved by their layer's offset. The collision layer never holds spawn
+// points.
+func (t *TileMapJSON) SpawnPoints() []*objects.ObjectJSON {
+	points := make([]*objects.ObjectJSON, 0)
+	for _, layer := range t.FlatLayers() {
+		if layer.Type != ObjectGroup || layer.Name == CollisionLayerName {
+			continue
 		}
 
-		ts = append(ts, tileset)
+		for _, object := range layer.Objects {
+			if object.Shape() == objects.Point && object.ClassName() != "" {
+				point := *object
+				point.X, point.Y = t.ObjectToPixel(object.X, object.Y)
+				point.X += layer.OffsetX
+				point.Y += layer.OffsetY
+				points = append(points, &point)
+			}
+		}
+	}
+
+	return points
+}
+
+// NewTileMap loads a map from either its JSON or its TMX file in fsys,
+// telling them apart by extension.
+func NewTileMap(fsys fs.FS, mapPath string) (*TileMapJSON, error) {
+	if strings.EqualFold(path.Ext(mapPath), ".tmx") {
+		return NewTileMapTMX(fsys, mapPath)
 	}
 
-	return ts, nil
+	return NewTileMapJSON(fsys, mapPath)
 }
 
-func NewTileMapJSON(filepath string) (*TileMapJSON, error) {
-	contents, err := os.ReadFile(filepath)
+func NewTileMapJSON(fsys fs.FS, mapPath string) (*TileMapJSON, error) {
+	contents, err := fs.ReadFile(fsys, mapPath)
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +314,12 @@ func NewTileMapJSON(filepath string) (*TileMapJSON, error) {
 
 	return &tileMapJson, nil
 }
+
+func NewTileMapTMX(fsys fs.FS, mapPath string) (*TileMapJSON, error) {
+	contents, err := fs.ReadFile(fsys, mapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTMX(contents)
+}
@@ -0,0 +1,136 @@
+package tilemaps
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+// custom tileset property holding how many pixels above the bottom of its
+// tile images sprites pass in front of them, see tilesets.Tileset.Anchor
+const AnchorProperty = "anchorY"
+
+type TileObjectGroupJSON struct {
+	Objects []*objects.ObjectJSON `json:"objects" xml:"object"`
+}
+
+type FrameJSON struct {
+	Duration int `json:"duration" xml:"duration,attr"` // milliseconds
+	TileId   int `json:"tileid" xml:"tileid,attr"`
+}
+
+type TileJSON struct {
+	Animation   []*FrameJSON          `json:"animation"`
+	Height      int                   `json:"imageheight"`
+	Id          int                   `json:"id"`
+	ObjectGroup *TileObjectGroupJSON  `json:"objectgroup"`
+	Path        string                `json:"image"`
+	Properties  properties.Properties `json:"properties"`
+	Width       int                   `json:"imagewidth"`
+}
+
+// Colliders returns the collision shapes Tiled stores in the tile's object
+// group, relative to the top left corner of its image.
+func (t *TileJSON) Colliders() []image.Rectangle {
+	if t.ObjectGroup == nil {
+		return nil
+	}
+
+	colliders := make([]image.Rectangle, 0)
+	for _, object := range t.ObjectGroup.Objects {
+		if object.HasArea() {
+			colliders = append(colliders, object.Bounds())
+		}
+	}
+
+	return colliders
+}
+
+type TilesetJSON struct {
+	Columns     int                   `json:"columns"`
+	ImageHeight int                   `json:"imageheight"`
+	ImageWidth  int                   `json:"imagewidth"`
+	Margin      int                   `json:"margin"`
+	Path        string                `json:"image"`
+	Properties  properties.Properties `json:"properties"`
+	Spacing     int                   `json:"spacing"`
+	TileCount   int                   `json:"tilecount"`
+	TileHeight  int                   `json:"tileheight"`
+	Tiles       []*TileJSON           `json:"tiles"`
+	TileWidth   int                   `json:"tilewidth"`
+	WangSets    []*WangSetJSON        `json:"wangsets"`
+}
+
+// IsCollection reports whether the tiles carry images of their own, as
+// opposed to all tiles being cut out of a single image.
+func (t *TilesetJSON) IsCollection() bool {
+	for _, tileJSON := range t.Tiles {
+		if tileJSON.Path != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Grid returns how many columns and tiles the tileset's image holds, given
+// its size. Older Tiled versions leave columns and tilecount out, so they're
+// derived from the image then; ImageWidth and ImageHeight are preferred to
+// the size passed in.
+func (t *TilesetJSON) Grid(imgWidth, imgHeight int) (int, int) {
+	if t.TileWidth <= 0 || t.TileHeight <= 0 {
+		return 0, 0
+	}
+
+	columns := t.Columns
+	if columns <= 0 {
+		if t.ImageWidth > 0 {
+			imgWidth = t.ImageWidth
+		}
+		columns = (imgWidth - 2*t.Margin + t.Spacing) / (t.TileWidth + t.Spacing)
+	}
+
+	tileCount := t.TileCount
+	if tileCount <= 0 {
+		if t.ImageHeight > 0 {
+			imgHeight = t.ImageHeight
+		}
+		rows := (imgHeight - 2*t.Margin + t.Spacing) / (t.TileHeight + t.Spacing)
+		tileCount = rows * columns
+	}
+
+	return columns, tileCount
+}
+
+// NewTilesetJSON reads a tileset from either its JSON or its TSX file in
+// fsys, telling them apart by extension.
+func NewTilesetJSON(fsys fs.FS, tilesetPath string) (*TilesetJSON, error) {
+	content, err := fs.ReadFile(fsys, tilesetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var tilesetJson TilesetJSON
+	if strings.EqualFold(path.Ext(tilesetPath), ".tsx") {
+		err = xml.Unmarshal(content, &tilesetJson)
+	} else {
+		err = json.Unmarshal(content, &tilesetJson)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tilesetPath, err)
+	}
+
+	return &tilesetJson, nil
+}
+
+// ResolvePath turns a path Tiled stored relative to a file in dir into a
+// path of the file system dir is in. Tiled writes backslashes on Windows.
+func ResolvePath(dir, rel string) string {
+	return path.Join(dir, strings.ReplaceAll(rel, "\\", "/"))
+}
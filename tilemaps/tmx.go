@@ -0,0 +1,262 @@
+package tilemaps
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+type tmxTiles struct {
+	Text string `xml:",chardata"`
+	// without an encoding, every gid is its own element
+	Tiles []struct {
+		Gid uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+}
+
+func (t *tmxTiles) decode(encoding, compression string) ([]uint32, error) {
+	switch encoding {
+	case "":
+		data := make([]uint32, len(t.Tiles))
+		for i, tile := range t.Tiles {
+			data[i] = tile.Gid
+		}
+		return data, nil
+	case CSV:
+		return decodeCSV(t.Text)
+	case Base64:
+		return decodeBase64(t.Text, compression)
+	}
+
+	return nil, fmt.Errorf("unsupported encoding %q", encoding)
+}
+
+type tmxChunk struct {
+	tmxTiles
+	Height int `xml:"height,attr"`
+	Width  int `xml:"width,attr"`
+	X      int `xml:"x,attr"`
+	Y      int `xml:"y,attr"`
+}
+
+type tmxData struct {
+	tmxTiles
+	Chunks      []*tmxChunk `xml:"chunk"`
+	Compression string      `xml:"compression,attr"`
+	Encoding    string      `xml:"encoding,attr"`
+}
+
+// UnmarshalXML reads a <tileset> element of a map, which either references a
+// TSX file or holds the whole tileset.
+func (r *TilesetRefJSON) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "firstgid":
+			firstGid, err := strconv.Atoi(attr.Value)
+			if err != nil {
+				return fmt.Errorf("tileset firstgid: %w", err)
+			}
+			r.FirstGid = firstGid
+		case "source":
+			r.Source = attr.Value
+		}
+	}
+
+	if r.Source != "" {
+		return d.Skip()
+	}
+
+	r.Embedded = &TilesetJSON{}
+	return d.DecodeElement(r.Embedded, &start)
+}
+
+func (d *tmxData) decodeInto(layer *TileMapLayerJSON) error {
+	if d == nil {
+		return nil
+	}
+
+	if len(d.Chunks) == 0 {
+		data, err := d.decode(d.Encoding, d.Compression)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 && layer.Width <= 0 {
+			return fmt.Errorf("has data but a width of %d", layer.Width)
+		}
+		layer.Data = data
+		return nil
+	}
+
+	for _, chunk := range d.Chunks {
+		data, err := chunk.decode(d.Encoding, d.Compression)
+		if err != nil {
+			return fmt.Errorf("chunk (%d, %d): %w", chunk.X, chunk.Y, err)
+		}
+		if len(data) > 0 && chunk.Width <= 0 {
+			return fmt.Errorf("chunk (%d, %d): has data but a width of %d", chunk.X, chunk.Y, chunk.Width)
+		}
+
+		layer.Chunks = append(layer.Chunks, &ChunkJSON{
+			Data:   data,
+			Height: chunk.Height,
+			Width:  chunk.Width,
+			X:      chunk.X,
+			Y:      chunk.Y,
+		})
+	}
+
+	return nil
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+}
+
+// tmxLayer holds any of the layer elements; which one it is comes from its
+// XMLName, so layers keep the order they have in the file.
+type tmxLayer struct {
+	Data       *tmxData              `xml:"data"`
+	Height     int                   `xml:"height,attr"`
+	Image      *tmxImage             `xml:"image"`
+	Layers     []tmxLayer            `xml:",any"` // only in groups
+	Name       string                `xml:"name,attr"`
+	Objects    []*objects.ObjectJSON `xml:"object"`
+	OffsetX    float64               `xml:"offsetx,attr"`
+	OffsetY    float64               `xml:"offsety,attr"`
+	Opacity    float64               `xml:"opacity,attr"`
+	ParallaxX  float64               `xml:"parallaxx,attr"`
+	ParallaxY  float64               `xml:"parallaxy,attr"`
+	Properties properties.Properties `xml:"properties>property"`
+	RepeatX    bool                  `xml:"repeatx,attr"`
+	RepeatY    bool                  `xml:"repeaty,attr"`
+	TintColor  properties.Color      `xml:"tintcolor,attr"`
+	Visible    bool                  `xml:"visible,attr"`
+	Width      int                   `xml:"width,attr"`
+	XMLName    xml.Name
+}
+
+func (l *tmxLayer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	defaults := defaultLayer()
+
+	// alias drops the method so DecodeElement doesn't recurse into it
+	type alias tmxLayer
+	layer := alias{
+		Opacity:   defaults.Opacity,
+		ParallaxX: defaults.ParallaxX,
+		ParallaxY: defaults.ParallaxY,
+		TintColor: defaults.TintColor,
+		Visible:   defaults.Visible,
+	}
+	if err := d.DecodeElement(&layer, &start); err != nil {
+		return err
+	}
+
+	*l = tmxLayer(layer)
+	return nil
+}
+
+type tmxMap struct {
+	Height          int                   `xml:"height,attr"`
+	HexSideLength   int                   `xml:"hexsidelength,attr"`
+	Infinite        bool                  `xml:"infinite,attr"`
+	Layers          []tmxLayer            `xml:",any"`
+	Orientation     string                `xml:"orientation,attr"`
+	ParallaxOriginX float64               `xml:"parallaxoriginx,attr"`
+	ParallaxOriginY float64               `xml:"parallaxoriginy,attr"`
+	Properties      properties.Properties `xml:"properties>property"`
+	StaggerAxis     string                `xml:"staggeraxis,attr"`
+	StaggerIndex    string                `xml:"staggerindex,attr"`
+	TileHeight      int                   `xml:"tileheight,attr"`
+	Tilesets        []*TilesetRefJSON     `xml:"tileset"`
+	TileWidth       int                   `xml:"tilewidth,attr"`
+	Width           int                   `xml:"width,attr"`
+}
+
+// parseTMX reads a map saved in Tiled's XML format into the same model the
+// JSON format is read into.
+func parseTMX(content []byte) (*TileMapJSON, error) {
+	var tmx tmxMap
+	if err := xml.Unmarshal(content, &tmx); err != nil {
+		return nil, err
+	}
+
+	tileMapJson := TileMapJSON{
+		Height:          tmx.Height,
+		HexSideLength:   tmx.HexSideLength,
+		Infinite:        tmx.Infinite,
+		Orientation:     tmx.Orientation,
+		ParallaxOriginX: tmx.ParallaxOriginX,
+		ParallaxOriginY: tmx.ParallaxOriginY,
+		Properties:      tmx.Properties,
+		StaggerAxis:     tmx.StaggerAxis,
+		StaggerIndex:    tmx.StaggerIndex,
+		TileHeight:      tmx.TileHeight,
+		Tilesets:        tmx.Tilesets,
+		TileWidth:       tmx.TileWidth,
+		Width:           tmx.Width,
+	}
+
+	layers, err := convertLayers(tmx.Layers)
+	if err != nil {
+		return nil, err
+	}
+
+	tileMapJson.Layers = layers
+
+	return &tileMapJson, nil
+}
+
+// convertLayers turns layer elements into layers, descending into groups.
+func convertLayers(tmxLayers []tmxLayer) ([]TileMapLayerJSON, error) {
+	layers := make([]TileMapLayerJSON, 0, len(tmxLayers))
+	for _, tmxLayer := range tmxLayers {
+		layer := TileMapLayerJSON{
+			Height:     tmxLayer.Height,
+			Name:       tmxLayer.Name,
+			OffsetX:    tmxLayer.OffsetX,
+			OffsetY:    tmxLayer.OffsetY,
+			Opacity:    tmxLayer.Opacity,
+			ParallaxX:  tmxLayer.ParallaxX,
+			ParallaxY:  tmxLayer.ParallaxY,
+			Properties: tmxLayer.Properties,
+			RepeatX:    tmxLayer.RepeatX,
+			RepeatY:    tmxLayer.RepeatY,
+			TintColor:  tmxLayer.TintColor,
+			Visible:    tmxLayer.Visible,
+			Width:      tmxLayer.Width,
+		}
+
+		switch tmxLayer.XMLName.Local {
+		case "group":
+			layer.Type = Group
+			children, err := convertLayers(tmxLayer.Layers)
+			if err != nil {
+				return nil, fmt.Errorf("group %q: %w", layer.Name, err)
+			}
+			layer.Layers = children
+		case "imagelayer":
+			layer.Type = ImageLayer
+			if tmxLayer.Image != nil {
+				layer.Image = tmxLayer.Image.Source
+			}
+		case "layer":
+			layer.Type = TileLayer
+			if err := tmxLayer.Data.decodeInto(&layer); err != nil {
+				return nil, fmt.Errorf("layer %q: %w", layer.Name, err)
+			}
+		case "objectgroup":
+			layer.Type = ObjectGroup
+			layer.Objects = tmxLayer.Objects
+		default:
+			// properties, editor settings and the like
+			continue
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
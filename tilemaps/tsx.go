@@ -0,0 +1,84 @@
+package tilemaps
+
+import (
+	"encoding/xml"
+
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+type tsxImage struct {
+	Height int    `xml:"height,attr"`
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+}
+
+type tsxTile struct {
+	Animation   []*FrameJSON          `xml:"animation>frame"`
+	Id          int                   `xml:"id,attr"`
+	Image       *tsxImage             `xml:"image"`
+	ObjectGroup *TileObjectGroupJSON  `xml:"objectgroup"`
+	Properties  properties.Properties `xml:"properties>property"`
+}
+
+type tsxTileset struct {
+	Columns    int                   `xml:"columns,attr"`
+	Image      *tsxImage             `xml:"image"`
+	Margin     int                   `xml:"margin,attr"`
+	Properties properties.Properties `xml:"properties>property"`
+	Spacing    int                   `xml:"spacing,attr"`
+	TileCount  int                   `xml:"tilecount,attr"`
+	TileHeight int                   `xml:"tileheight,attr"`
+	Tiles      []tsxTile             `xml:"tile"`
+	TileWidth  int                   `xml:"tilewidth,attr"`
+	WangSets   []*WangSetJSON        `xml:"wangsets>wangset"`
+}
+
+// UnmarshalXML reads a <tileset> element, from a TSX file or embedded in a
+// TMX map, into the same model the JSON format is read into.
+func (t *TilesetJSON) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var tsx tsxTileset
+	if err := d.DecodeElement(&tsx, &start); err != nil {
+		return err
+	}
+
+	*t = *tsx.toJSON()
+	return nil
+}
+
+func (t *tsxTileset) toJSON() *TilesetJSON {
+	tilesetJson := TilesetJSON{
+		Columns:    t.Columns,
+		Margin:     t.Margin,
+		Properties: t.Properties,
+		Spacing:    t.Spacing,
+		TileCount:  t.TileCount,
+		TileHeight: t.TileHeight,
+		Tiles:      make([]*TileJSON, 0, len(t.Tiles)),
+		TileWidth:  t.TileWidth,
+		WangSets:   t.WangSets,
+	}
+
+	if t.Image != nil {
+		tilesetJson.ImageHeight = t.Image.Height
+		tilesetJson.ImageWidth = t.Image.Width
+		tilesetJson.Path = t.Image.Source
+	}
+
+	for _, tile := range t.Tiles {
+		tileJSON := TileJSON{
+			Animation:   tile.Animation,
+			Id:          tile.Id,
+			ObjectGroup: tile.ObjectGroup,
+			Properties:  tile.Properties,
+		}
+		if tile.Image != nil {
+			tileJSON.Height = tile.Image.Height
+			tileJSON.Path = tile.Image.Source
+			tileJSON.Width = tile.Image.Width
+		}
+
+		tilesetJson.Tiles = append(tilesetJson.Tiles, &tileJSON)
+	}
+
+	return &tilesetJson
+}
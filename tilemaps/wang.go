@@ -0,0 +1,98 @@
+package tilemaps
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+)
+
+// wang set types, picking which parts of a tile its terrain is given for
+const (
+	WangCorner = "corner"
+	WangEdge   = "edge"
+	WangMixed  = "mixed"
+)
+
+// indices into a WangId, clockwise from the top edge
+const (
+	WangTop = iota
+	WangTopRight
+	WangRight
+	WangBottomRight
+	WangBottom
+	WangBottomLeft
+	WangLeft
+	WangTopLeft
+)
+
+// WangId holds the wang color of each edge and corner of a tile, starting at
+// the top edge and going clockwise. Colors are indices into the wang set's
+// colors starting at 1, 0 leaves the part without terrain.
+type WangId [8]int
+
+// UnmarshalXMLAttr reads the comma separated list TSX files store wang ids
+// as.
+func (w *WangId) UnmarshalXMLAttr(attr xml.Attr) error {
+	values := strings.Split(attr.Value, ",")
+	if len(values) != len(w) {
+		return fmt.Errorf("malformed wang id %q", attr.Value)
+	}
+
+	for i, value := range values {
+		color, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("malformed wang id %q", attr.Value)
+		}
+		w[i] = color
+	}
+
+	return nil
+}
+
+// Masked returns the wang id with the parts a wang set of type wangType
+// doesn't use cleared: edges for corner sets, corners for edge sets.
+func (w WangId) Masked(wangType string) WangId {
+	for i := range w {
+		corner := i%2 == 1
+		if wangType == WangCorner && !corner || wangType == WangEdge && corner {
+			w[i] = 0
+		}
+	}
+	return w
+}
+
+type WangColorJSON struct {
+	Color       properties.Color `json:"color" xml:"color,attr"`
+	Name        string           `json:"name" xml:"name,attr"`
+	Probability float64          `json:"probability" xml:"probability,attr"`
+	Tile        int              `json:"tile" xml:"tile,attr"`
+}
+
+type WangTileJSON struct {
+	TileId int    `json:"tileid" xml:"tileid,attr"`
+	WangId WangId `json:"wangid" xml:"wangid,attr"`
+}
+
+// WangSetJSON is a set of terrains, its colors, and which tiles of the
+// tileset show which terrain at each of their edges and corners.
+type WangSetJSON struct {
+	Colors    []*WangColorJSON `json:"colors" xml:"wangcolor"`
+	Name      string           `json:"name" xml:"name,attr"`
+	Tile      int              `json:"tile" xml:"tile,attr"`
+	Type      string           `json:"type" xml:"type,attr"`
+	WangTiles []*WangTileJSON  `json:"wangtiles" xml:"wangtile"`
+}
+
+// Color returns the index of the named color, 0 if the set has none by that
+// name.
+func (w *WangSetJSON) Color(name string) int {
+	for i, color := range w.Colors {
+		if color.Name == name {
+			return i + 1
+		}
+	}
+	return 0
+}
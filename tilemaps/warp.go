@@ -0,0 +1,64 @@
+package tilemaps
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/ev-the-dev/rpg-tutorial/objects"
+)
+
+// objects of this class take the player to another map when walked into
+const WarpClass = "warp"
+
+// Warp is an area of a map that takes the player to a spawn point of
+// another map. Designers set its target with the warp object's "map" and
+// "spawn" properties.
+type Warp struct {
+	Area  image.Rectangle // map pixels
+	Map   string          // path of the target map, relative to this map's file
+	Spawn string          // name of the player spawn point to arrive at, any if empty
+}
+
+// Warps returns the warp objects of every object layer but the collision
+// layer, moved by their layer's offset.
+func (t *TileMapJSON) Warps() ([]*Warp, error) {
+	warps := make([]*Warp, 0)
+	for _, layer := range t.FlatLayers() {
+		if layer.Type != ObjectGroup || layer.Name == CollisionLayerName {
+			continue
+		}
+
+		for _, object := range layer.Objects {
+			if object.ClassName() != WarpClass {
+				continue
+			}
+
+			warp, err := newWarp(object, t.ObjectBounds(object))
+			if err != nil {
+				return nil, fmt.Errorf("layer %q warp %d: %w", layer.Name, object.Id, err)
+			}
+
+			warp.Area = warp.Area.Add(layer.Offset())
+			warps = append(warps, warp)
+		}
+	}
+
+	return warps, nil
+}
+
+func newWarp(object *objects.ObjectJSON, area image.Rectangle) (*Warp, error) {
+	if !object.HasArea() {
+		return nil, fmt.Errorf("encloses no area")
+	}
+
+	mapPath := object.Properties.String("map", "")
+	if mapPath == "" {
+		return nil, fmt.Errorf("has no map property")
+	}
+
+	return &Warp{
+		Area:  area,
+		Map:   mapPath,
+		Spawn: object.Properties.String("spawn", ""),
+	}, nil
+}
@@ -0,0 +1,165 @@
+package tilesets
+
+import (
+	"fmt"
+	"image"
+	"path"
+	"sort"
+
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Set resolves the global tile ids of a map to the tileset that owns them,
+// regardless of which layer the tile was placed on.
+type Set struct {
+	firstGids []int
+	tilesets  []Tileset
+}
+
+func NewSet(ts []Tileset) *Set {
+	sorted := make([]Tileset, len(ts))
+	copy(sorted, ts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].FirstGid() < sorted[j].FirstGid()
+	})
+
+	firstGids := make([]int, len(sorted))
+	for i, tileset := range sorted {
+		firstGids[i] = tileset.FirstGid()
+	}
+
+	return &Set{
+		firstGids: firstGids,
+		tilesets:  sorted,
+	}
+}
+
+// NewSetFromMap loads the tilesets a map refers to through loader, whether
+// they're embedded in it or in files of their own. mapPath is where the map
+// is, which their paths are relative to.
+func NewSetFromMap(loader Loader, mapPath string, tileMap *tilemaps.TileMapJSON) (*Set, error) {
+	ts := make([]Tileset, 0)
+	for i, ref := range tileMap.Tilesets {
+		if ref.FirstGid <= 0 {
+			return nil, fmt.Errorf("tileset %d: firstgid %d is not positive", i, ref.FirstGid)
+		}
+
+		var tileset Tileset
+		var err error
+		if ref.Embedded != nil {
+			tileset, err = NewTilesetFromJSON(loader, ref.Embedded, path.Dir(mapPath), ref.FirstGid)
+		} else {
+			tileset, err = NewTileset(loader, tilemaps.ResolvePath(path.Dir(mapPath), ref.Source), ref.FirstGid)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tileset %d: %w", i, err)
+		}
+
+		ts = append(ts, tileset)
+	}
+
+	return NewSet(ts), nil
+}
+
+// Tileset returns the tileset with the highest first gid not above gid.
+func (s *Set) Tileset(gid int) (Tileset, error) {
+	idx := sort.SearchInts(s.firstGids, gid+1) - 1
+	if idx < 0 {
+		return nil, fmt.Errorf("gid %d: no tileset owns it", gid)
+	}
+
+	return s.tilesets[idx], nil
+}
+
+// Anchor returns the anchor of the tileset owning gid, see Tileset.Anchor.
+// Unknown gids are anchored at the bottom of their image.
+func (s *Set) Anchor(gid int) int {
+	tileset, err := s.Tileset(gid)
+	if err != nil {
+		return 0
+	}
+
+	return tileset.Anchor()
+}
+
+// Animated reports whether the tile gid changes over time. Unknown gids
+// aren't animated.
+func (s *Set) Animated(gid int) bool {
+	tileset, err := s.Tileset(gid)
+	if err != nil {
+		return false
+	}
+
+	return tileset.Animated(gid)
+}
+
+func (s *Set) Colliders(gid int) ([]image.Rectangle, error) {
+	tileset, err := s.Tileset(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	return tileset.Colliders(gid), nil
+}
+
+func (s *Set) Img(gid int) (*ebiten.Image, error) {
+	tileset, err := s.Tileset(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	img := tileset.Img(gid)
+	if img == nil {
+		return nil, fmt.Errorf("gid %d: past the last tile of its tileset", gid)
+	}
+
+	return img, nil
+}
+
+// Size returns the size of the image of the tile gid.
+func (s *Set) Size(gid int) (image.Point, error) {
+	img, err := s.Img(gid)
+	if err != nil {
+		return image.Point{}, err
+	}
+
+	return img.Bounds().Size(), nil
+}
+
+// Frame returns the gid of the tile to show for gid after ms milliseconds of
+// game time. Unknown gids are returned as they are.
+func (s *Set) Frame(gid int, ms int) int {
+	tileset, err := s.Tileset(gid)
+	if err != nil {
+		return gid
+	}
+
+	return tileset.Frame(gid, ms)
+}
+
+// TileProperties returns the custom properties of the tile gid, e.g. the
+// terrain it's made of. Unknown gids have none.
+func (s *Set) TileProperties(gid int) properties.Properties {
+	tileset, err := s.Tileset(gid)
+	if err != nil {
+		return nil
+	}
+
+	return tileset.TileProperties(gid)
+}
+
+// WangSet finds the wang set called name in any of the tilesets, returning
+// it together with the first gid of the tileset it belongs to.
+func (s *Set) WangSet(name string) (*tilemaps.WangSetJSON, int, error) {
+	for _, tileset := range s.tilesets {
+		for _, wangSet := range tileset.WangSets() {
+			if wangSet.Name == name {
+				return wangSet, tileset.FirstGid(), nil
+			}
+		}
+	}
+
+	return nil, 0, fmt.Errorf("no wang set named %q", name)
+}
@@ -1,58 +1,141 @@
 package tilesets
 
 import (
-	"encoding/json"
+	"fmt"
 	"image"
-	"os"
-	"path/filepath"
-	"strings"
+	"path"
 
-	"github.com/ev-the-dev/rpg-tutorial/constants"
+	"github.com/ev-the-dev/rpg-tutorial/properties"
+	"github.com/ev-the-dev/rpg-tutorial/tilemaps"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
+// Loader loads the files tilesets are made of, e.g. through an
+// assets.Scope so they're shared with everything else using them.
+type Loader interface {
+	Image(imgPath string) (*ebiten.Image, error)
+	Tileset(tilesetPath string) (*tilemaps.TilesetJSON, error)
+}
+
 type Tileset interface {
+	// Anchor returns how far above the bottom of a tile image its foot is,
+	// in pixels. Tiles and sprites are drawn in the order of their feet.
+	Anchor() int
+	// Animated reports whether tile id changes over time, see Frame.
+	Animated(id int) bool
+	// Colliders returns the collision shapes of a tile relative to the
+	// top left corner of its image.
+	Colliders(id int) []image.Rectangle
+	FirstGid() int
+	// Frame returns the id of the tile to show for tile id after ms
+	// milliseconds of game time, which is id itself unless it's animated.
+	Frame(id int, ms int) int
 	Img(id int) *ebiten.Image
+	Properties() properties.Properties
+	// TileProperties returns the custom properties of tile id.
+	TileProperties(id int) properties.Properties
+	// WangSets returns the tileset's terrains, see tilemaps.WangSetJSON.
+	WangSets() []*tilemaps.WangSetJSON
 }
 
-type UniformTilesetJSON struct {
-	Path string `json:"image"`
+type UniformTileset struct {
+	anchor     int
+	animations map[int]*tileAnimation
+	colliders  map[int][]image.Rectangle
+	columns    int
+	gid        int
+	img        *ebiten.Image
+	margin     int
+	properties properties.Properties
+	spacing    int
+	tileCount  int
+	tileHeight int
+	tileProps  map[int]properties.Properties
+	tileWidth  int
+	wangSets   []*tilemaps.WangSetJSON
 }
 
-type UniformTileset struct {
-	gid int
-	img *ebiten.Image
+func (u *UniformTileset) Anchor() int {
+	return u.anchor
+}
+
+func (u *UniformTileset) Animated(id int) bool {
+	_, exists := u.animations[id-u.gid]
+	return exists
+}
+
+func (u *UniformTileset) Colliders(id int) []image.Rectangle {
+	return u.colliders[id-u.gid]
+}
+
+func (u *UniformTileset) FirstGid() int {
+	return u.gid
+}
+
+func (u *UniformTileset) Frame(id int, ms int) int {
+	return frame(u.animations, u.gid, id, ms)
 }
 
 func (u *UniformTileset) Img(id int) *ebiten.Image {
 	id -= u.gid
+	if id < 0 || id >= u.tileCount {
+		return nil
+	}
+
 	// get the position on the TileSet image where the tile ID is
-	srcX := (id) % 22 // 22 hardcoded because tileset file shows last index on row as id 21 (0th based)
-	srcY := (id) / 22
+	srcX := id % u.columns
+	srcY := id / u.columns
 	// convert the src tile position to src pixel position
-	srcX *= constants.Tilesize
-	srcY *= constants.Tilesize
+	srcX = u.margin + srcX*(u.tileWidth+u.spacing)
+	srcY = u.margin + srcY*(u.tileHeight+u.spacing)
 
 	return u.img.SubImage(
-		image.Rect(srcX, srcY, srcX+constants.Tilesize, srcY+constants.Tilesize),
+		image.Rect(srcX, srcY, srcX+u.tileWidth, srcY+u.tileHeight),
 	).(*ebiten.Image)
 }
 
-type TileJSON struct {
-	Height int    `json:"imageheight"`
-	Id     int    `json:"id"`
-	Path   string `json:"image"`
-	Width  int    `json:"imagewidth"`
+func (u *UniformTileset) Properties() properties.Properties {
+	return u.properties
+}
+
+func (u *UniformTileset) TileProperties(id int) properties.Properties {
+	return u.tileProps[id-u.gid]
 }
 
-type DynamicTilesetJSON struct {
-	Tiles []*TileJSON `json:"tiles"`
+func (u *UniformTileset) WangSets() []*tilemaps.WangSetJSON {
+	return u.wangSets
 }
 
 type DynamicTileset struct {
-	gid  int
-	imgs []*ebiten.Image
+	anchor     int
+	animations map[int]*tileAnimation
+	colliders  map[int][]image.Rectangle
+	gid        int
+	imgs       map[int]*ebiten.Image
+	properties properties.Properties
+	tileProps  map[int]properties.Properties
+	wangSets   []*tilemaps.WangSetJSON
+}
+
+func (d *DynamicTileset) Anchor() int {
+	return d.anchor
+}
+
+func (d *DynamicTileset) Animated(id int) bool {
+	_, exists := d.animations[id-d.gid]
+	return exists
+}
+
+func (d *DynamicTileset) Colliders(id int) []image.Rectangle {
+	return d.colliders[id-d.gid]
+}
+
+func (d *DynamicTileset) FirstGid() int {
+	return d.gid
+}
+
+func (d *DynamicTileset) Frame(id int, ms int) int {
+	return frame(d.animations, d.gid, id, ms)
 }
 
 func (d *DynamicTileset) Img(id int) *ebiten.Image {
@@ -61,65 +144,160 @@ func (d *DynamicTileset) Img(id int) *ebiten.Image {
 	return d.imgs[id]
 }
 
-func NewTileset(path string, gid int) (Tileset, error) {
-	// temporary
+func (d *DynamicTileset) Properties() properties.Properties {
+	return d.properties
+}
+
+func (d *DynamicTileset) TileProperties(id int) properties.Properties {
+	return d.tileProps[id-d.gid]
+}
+
+func (d *DynamicTileset) WangSets() []*tilemaps.WangSetJSON {
+	return d.wangSets
+}
 
-	content, err := os.ReadFile(path)
+// NewTileset loads a tileset from either its JSON or its TSX file through
+// loader.
+func NewTileset(loader Loader, tilesetPath string, gid int) (Tileset, error) {
+	tilesetJson, err := loader.Tileset(tilesetPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.Contains(path, "TilesetBuilding") {
-		// return dynamic tileset
-		var dynamicTilesetJson DynamicTilesetJSON
-		err = json.Unmarshal(content, &dynamicTilesetJson)
-		if err != nil {
-			return nil, err
-		}
+	return NewTilesetFromJSON(loader, tilesetJson, path.Dir(tilesetPath), gid)
+}
 
+// NewTilesetFromJSON builds a tileset out of already parsed tileset data,
+// e.g. one embedded in a map, loading its images through loader. dir is the
+// directory the image paths are relative to.
+func NewTilesetFromJSON(loader Loader, tilesetJson *tilemaps.TilesetJSON, dir string, gid int) (Tileset, error) {
+	if tilesetJson.IsCollection() {
+		// return dynamic tileset
 		dynamicTileset := DynamicTileset{
-			gid:  gid,
-			imgs: make([]*ebiten.Image, 0),
+			anchor:     tilesetJson.Properties.Int(tilemaps.AnchorProperty, 0),
+			animations: tileAnimations(tilesetJson.Tiles),
+			colliders:  tileColliders(tilesetJson.Tiles),
+			gid:        gid,
+			imgs:       make(map[int]*ebiten.Image),
+			properties: tilesetJson.Properties,
+			tileProps:  tileProperties(tilesetJson.Tiles),
+			wangSets:   tilesetJson.WangSets,
 		}
 
-		for _, tileJSON := range dynamicTilesetJson.Tiles {
-
-			tileJSONPath := filepath.Clean(tileJSON.Path)
-			tileJSONPath = strings.ReplaceAll(tileJSONPath, "\\", "/")
-			tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-			tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-			tileJSONPath = filepath.Join("assets/", tileJSONPath)
+		for _, tileJSON := range tilesetJson.Tiles {
+			if tileJSON.Path == "" {
+				continue
+			}
 
-			img, _, err := ebitenutil.NewImageFromFile(tileJSONPath)
+			img, err := loader.Image(tilemaps.ResolvePath(dir, tileJSON.Path))
 			if err != nil {
 				return nil, err
 			}
 
-			dynamicTileset.imgs = append(dynamicTileset.imgs, img)
+			dynamicTileset.imgs[tileJSON.Id] = img
 		}
 
 		return &dynamicTileset, nil
 	}
 
-	// return inform tileset
-	var uniformTilesetJson UniformTilesetJSON
-	err = json.Unmarshal(content, &uniformTilesetJson)
-	if err != nil {
-		return nil, err
+	// return uniform tileset
+	if tilesetJson.Path == "" {
+		return nil, fmt.Errorf("tileset has no image")
+	}
+	if tilesetJson.TileWidth <= 0 || tilesetJson.TileHeight <= 0 {
+		return nil, fmt.Errorf("tile size %dx%d is not positive", tilesetJson.TileWidth, tilesetJson.TileHeight)
+	}
+
+	uniformTileset := UniformTileset{
+		anchor:     tilesetJson.Properties.Int(tilemaps.AnchorProperty, 0),
+		animations: tileAnimations(tilesetJson.Tiles),
+		colliders:  tileColliders(tilesetJson.Tiles),
+		gid:        gid,
+		margin:     tilesetJson.Margin,
+		properties: tilesetJson.Properties,
+		spacing:    tilesetJson.Spacing,
+		tileHeight: tilesetJson.TileHeight,
+		tileProps:  tileProperties(tilesetJson.Tiles),
+		tileWidth:  tilesetJson.TileWidth,
+		wangSets:   tilesetJson.WangSets,
 	}
 
-	uniformTileset := UniformTileset{gid: gid}
-	tileJSONPath := filepath.Clean(uniformTilesetJson.Path)
-	tileJSONPath = strings.ReplaceAll(tileJSONPath, "\\", "/")
-	tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-	tileJSONPath = strings.TrimPrefix(tileJSONPath, "../")
-	tileJSONPath = filepath.Join("assets/", tileJSONPath)
-	img, _, err := ebitenutil.NewImageFromFile(tileJSONPath)
+	img, err := loader.Image(tilemaps.ResolvePath(dir, tilesetJson.Path))
 	if err != nil {
 		return nil, err
 	}
 
+	uniformTileset.columns, uniformTileset.tileCount = tilesetJson.Grid(img.Bounds().Dx(), img.Bounds().Dy())
 	uniformTileset.img = img
 
 	return &uniformTileset, nil
 }
+
+type tileAnimation struct {
+	frames []*tilemaps.FrameJSON
+	length int // milliseconds
+}
+
+// tileAnimations collects the animated tiles of a tileset, keyed by tile id.
+// Animations whose frames add up to no time at all can't advance, so they're
+// left out.
+func tileAnimations(tiles []*tilemaps.TileJSON) map[int]*tileAnimation {
+	animations := make(map[int]*tileAnimation)
+	for _, tileJSON := range tiles {
+		animation := tileAnimation{frames: tileJSON.Animation}
+		for _, frame := range tileJSON.Animation {
+			animation.length += max(frame.Duration, 0)
+		}
+
+		if animation.length > 0 {
+			animations[tileJSON.Id] = &animation
+		}
+	}
+
+	return animations
+}
+
+// frame looks up the global id of the frame shown for a global tile id after
+// ms milliseconds, looping the animation.
+func frame(animations map[int]*tileAnimation, gid, id, ms int) int {
+	animation, exists := animations[id-gid]
+	if !exists {
+		return id
+	}
+
+	ms %= animation.length
+	for _, frame := range animation.frames {
+		ms -= max(frame.Duration, 0)
+		if ms < 0 {
+			return gid + frame.TileId
+		}
+	}
+
+	return id
+}
+
+// tileColliders collects the collision shapes of the tiles that have any,
+// keyed by tile id.
+func tileColliders(tiles []*tilemaps.TileJSON) map[int][]image.Rectangle {
+	colliders := make(map[int][]image.Rectangle)
+	for _, tileJSON := range tiles {
+		if tileColliders := tileJSON.Colliders(); len(tileColliders) > 0 {
+			colliders[tileJSON.Id] = tileColliders
+		}
+	}
+
+	return colliders
+}
+
+// tileProperties collects the custom properties of the tiles that have any,
+// keyed by tile id.
+func tileProperties(tiles []*tilemaps.TileJSON) map[int]properties.Properties {
+	props := make(map[int]properties.Properties)
+	for _, tileJSON := range tiles {
+		if len(tileJSON.Properties) > 0 {
+			props[tileJSON.Id] = tileJSON.Properties
+		}
+	}
+
+	return props
+}
@@ -0,0 +1,178 @@
+package worlds
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+)
+
+// MapJSON places a map in a world. X and Y are world pixels and can be
+// negative.
+type MapJSON struct {
+	FileName string `json:"fileName"` // relative to the world file
+	Height   int    `json:"height"`
+	Width    int    `json:"width"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+}
+
+func (m *MapJSON) Bounds() image.Rectangle {
+	return image.Rect(m.X, m.Y, m.X+m.Width, m.Y+m.Height)
+}
+
+// PatternJSON places every map in the world file's directory whose file name
+// matches Regexp. Its first two capture groups are the map's column and row,
+// which the multipliers turn into pixels.
+type PatternJSON struct {
+	MultiplierX int    `json:"multiplierX"`
+	MultiplierY int    `json:"multiplierY"`
+	OffsetX     int    `json:"offsetX"`
+	OffsetY     int    `json:"offsetY"`
+	Regexp      string `json:"regexp"`
+}
+
+type WorldJSON struct {
+	Maps     []*MapJSON     `json:"maps"`
+	Patterns []*PatternJSON `json:"patterns"`
+	Type     string         `json:"type"`
+}
+
+// World is a set of maps laid out next to each other in one pixel space, as
+// described by a Tiled .world file.
+type World struct {
+	dir  string
+	maps []*MapJSON
+}
+
+// NewWorld reads the world file at worldPath in fsys.
+func NewWorld(fsys fs.FS, worldPath string) (*World, error) {
+	contents, err := fs.ReadFile(fsys, worldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var worldJson WorldJSON
+	if err := json.Unmarshal(contents, &worldJson); err != nil {
+		return nil, fmt.Errorf("%s: %w", worldPath, err)
+	}
+
+	world := World{
+		dir:  path.Dir(worldPath),
+		maps: worldJson.Maps,
+	}
+
+	if len(worldJson.Patterns) == 0 {
+		return &world, nil
+	}
+
+	entries, err := fs.ReadDir(fsys, world.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, pattern := range worldJson.Patterns {
+		re, err := regexp.Compile(pattern.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: pattern %d: %w", worldPath, i, err)
+		}
+		if re.NumSubexp() < 2 {
+			return nil, fmt.Errorf("%s: pattern %d: needs capture groups for the column and row", worldPath, i)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || world.Map(path.Join(world.dir, entry.Name())) != nil {
+				continue
+			}
+
+			match := re.FindStringSubmatch(entry.Name())
+			if match == nil {
+				continue
+			}
+
+			column, errX := strconv.Atoi(match[1])
+			row, errY := strconv.Atoi(match[2])
+			if errX != nil || errY != nil {
+				continue
+			}
+
+			// Tiled reads the size from the map itself, but the multipliers
+			// are the map size for any world without gaps or overlaps
+			world.maps = append(world.maps, &MapJSON{
+				FileName: entry.Name(),
+				Height:   pattern.MultiplierY,
+				Width:    pattern.MultiplierX,
+				X:        column*pattern.MultiplierX + pattern.OffsetX,
+				Y:        row*pattern.MultiplierY + pattern.OffsetY,
+			})
+		}
+	}
+
+	return &world, nil
+}
+
+// FindWorld returns the world of the first .world file next to the map at
+// mapPath in fsys that places it, or nil if none does.
+func FindWorld(fsys fs.FS, mapPath string) (*World, error) {
+	worldPaths, err := fs.Glob(fsys, path.Join(path.Dir(mapPath), "*.world"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, worldPath := range worldPaths {
+		world, err := NewWorld(fsys, worldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if world.Map(mapPath) != nil {
+			return world, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Bounds returns the area covered by all of the world's maps.
+func (w *World) Bounds() image.Rectangle {
+	bounds := image.Rectangle{}
+	for _, m := range w.maps {
+		bounds = bounds.Union(m.Bounds())
+	}
+
+	return bounds
+}
+
+// Map returns where the map at mapPath is placed, or nil if it isn't part
+// of the world.
+func (w *World) Map(mapPath string) *MapJSON {
+	mapPath = path.Clean(mapPath)
+	for _, m := range w.maps {
+		if w.Path(m) == mapPath {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// Near returns the maps overlapping area, in world pixels.
+func (w *World) Near(area image.Rectangle) []*MapJSON {
+	near := make([]*MapJSON, 0)
+	for _, m := range w.maps {
+		if m.Bounds().Overlaps(area) {
+			near = append(near, m)
+		}
+	}
+
+	return near
+}
+
+// Path returns the path of a map of the world in the file system the world
+// was read from.
+func (w *World) Path(m *MapJSON) string {
+	return path.Join(w.dir, m.FileName)
+}
@@ -0,0 +1,133 @@
+package worlds
+
+import (
+	"image"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testdata holds a world placing its maps one by one, and one placing them
+// by a pattern of their file names.
+var testdata = os.DirFS("testdata")
+
+func TestExplicitWorld(t *testing.T) {
+	world, err := NewWorld(testdata, "explicit/island.world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mapPath string
+		want    image.Rectangle
+	}{
+		{"explicit/town.json", image.Rect(0, 0, 320, 240)},
+		{"explicit/forest.json", image.Rect(320, 0, 640, 240)},
+		{"explicit/caves/../caves/cave.json", image.Rect(-160, 240, 320, 400)},
+	}
+
+	for _, test := range tests {
+		m := world.Map(test.mapPath)
+		if m == nil {
+			t.Errorf("%s: not in the world", test.mapPath)
+			continue
+		}
+		if m.Bounds() != test.want {
+			t.Errorf("%s: got bounds %v, want %v", test.mapPath, m.Bounds(), test.want)
+		}
+	}
+
+	if world.Map("explicit/desert.json") != nil {
+		t.Error("got a map the world doesn't place")
+	}
+
+	if want := image.Rect(-160, 0, 640, 400); world.Bounds() != want {
+		t.Errorf("got world bounds %v, want %v", world.Bounds(), want)
+	}
+}
+
+func TestPatternWorld(t *testing.T) {
+	world, err := NewWorld(testdata, "pattern/grid.world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mapPath string
+		want    image.Rectangle
+	}{
+		{"pattern/field_0_0.json", image.Rect(-320, 0, 0, 240)},
+		{"pattern/field_1_0.json", image.Rect(0, 0, 320, 240)},
+		{"pattern/field_2_1.json", image.Rect(320, 240, 640, 480)},
+	}
+
+	for _, test := range tests {
+		m := world.Map(test.mapPath)
+		if m == nil {
+			t.Errorf("%s: not in the world", test.mapPath)
+			continue
+		}
+		if m.Bounds() != test.want {
+			t.Errorf("%s: got bounds %v, want %v", test.mapPath, m.Bounds(), test.want)
+		}
+	}
+
+	for _, mapPath := range []string{"pattern/lonely.json", "pattern/field_notes.txt"} {
+		if world.Map(mapPath) != nil {
+			t.Errorf("%s: placed though the pattern doesn't match it", mapPath)
+		}
+	}
+}
+
+func TestNear(t *testing.T) {
+	world, err := NewWorld(testdata, "explicit/island.world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		area image.Rectangle
+		want []string
+	}{
+		{"inside one map", image.Rect(10, 10, 50, 50), []string{"town.json"}},
+		{"up to the shared edge", image.Rect(300, 10, 320, 50), []string{"town.json"}},
+		{"from the shared edge", image.Rect(320, 10, 340, 50), []string{"forest.json"}},
+		{"across the shared edge", image.Rect(319, 10, 321, 50), []string{"forest.json", "town.json"}},
+		{"across a corner", image.Rect(310, 230, 330, 250), []string{"caves/cave.json", "forest.json", "town.json"}},
+		{"past the edge of the world", image.Rect(640, 0, 700, 240), []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, m := range world.Near(test.area) {
+				got = append(got, m.FileName)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindWorld(t *testing.T) {
+	world, err := FindWorld(testdata, "pattern/field_1_0.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if world == nil || world.Map("pattern/field_1_0.json") == nil {
+		t.Error("didn't find the world placing the map")
+	}
+
+	world, err = FindWorld(testdata, "pattern/lonely.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if world != nil {
+		t.Error("found a world for a map no world places")
+	}
+}